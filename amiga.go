@@ -0,0 +1,267 @@
+package magicnumber
+
+// Package file amiga.go contains the functions that parse the Amiga Disk File floppy images
+// of the original and fast file systems, which store the volume name and the files of the disk.
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var ErrADFHeader = errors.New("amiga disk file is invalid")
+
+// AmigaDisk is the boot block and the root directory of an Amiga Disk File floppy image.
+type AmigaDisk struct {
+	FileSystem    string      // FileSystem is OFS for the original or FFS for the fast file system, or NDOS for a non-DOS disk
+	International bool        // International is true when the filenames use the international character mode
+	DirCache      bool        // DirCache is true when the file system uses the directory cache blocks
+	Bootable      bool        // Bootable is true when the boot block checksum is valid
+	HighDensity   bool        // HighDensity is true for a 1760 KiB image, otherwise it is a 880 KiB image
+	Volume        string      // Volume is the volume name of the disk
+	Created       time.Time   // Created is the creation date of the volume
+	Modified      time.Time   // Modified is the last modification date of the volume
+	Files         []AmigaFile // Files are the files and directories of the disk sorted by path
+}
+
+// AmigaFile is a file or a directory of an Amiga Disk File floppy image.
+type AmigaFile struct {
+	Name     string    // Name is the path of the file with the directories separated by a slash
+	Size     int64     // Size is the file size in bytes, or 0 for a directory
+	Dir      bool      // Dir is true when the entry is a directory
+	Comment  string    // Comment is the file note, which is shown by the Workbench and the List command
+	Modified time.Time // Modified is the last modification date of the file
+}
+
+const (
+	adfBlockLen = 512             // adfBlockLen is the size of a disk block
+	adfBootLen  = 1024            // adfBootLen is the size of the boot block, which uses the first two blocks
+	adfDD       = 1760 * 512      // adfDD is the size of a double density 880 KiB floppy disk
+	adfHD       = 3520 * 512      // adfHD is the size of a high density 1760 KiB floppy disk
+	adfDDRoot   = adfDD / 2 / 512 // adfDDRoot is the root block in the middle of a double density disk
+	adfHDRoot   = adfHD / 2 / 512 // adfHDRoot is the root block in the middle of a high density disk
+)
+
+// ADFHeaders parses the boot block and the root block of an Amiga Disk File floppy image
+// and returns the file system, the volume name and the listing of the files and directories.
+//
+// Non-DOS disks, such as the many demos and games that use their own track loaders,
+// have no file system, so only the boot block information is returned.
+func ADFHeaders(r io.ReaderAt) (AmigaDisk, error) {
+	if r == nil {
+		return AmigaDisk{}, ErrNilReader
+	}
+	if !Adf(r) {
+		return AmigaDisk{}, fmt.Errorf("%w: no signature", ErrADFHeader)
+	}
+	boot := make([]byte, adfBootLen)
+	if _, err := r.ReadAt(boot, 0); err != nil {
+		return AmigaDisk{}, fmt.Errorf("%w: boot block", ErrADFHeader)
+	}
+	disk := AmigaDisk{
+		FileSystem:  "NDOS",
+		Bootable:    adfBootable(boot),
+		HighDensity: Length(r) == adfHD,
+	}
+	key, found := adfRoot(r)
+	if !adfDOS(boot) || !found {
+		return disk, nil
+	}
+	// the flags of the dostype are 1 for the fast file system, 2 for the international mode
+	// and 4 for the directory cache, which also uses the international mode,
+	// while 6 and 7 are the long filename file systems of AmigaOS 3.2
+	const ffs, intl, cache = 1, 2, 4
+	flags := boot[3]
+	disk.FileSystem = "OFS"
+	if flags&ffs != 0 {
+		disk.FileSystem = "FFS"
+	}
+	disk.International = flags&(intl|cache) != 0
+	disk.DirCache = flags&cache != 0 && flags&intl == 0
+	disk.HighDensity = key == adfHDRoot
+	root, err := adfBlock(r, key)
+	if err != nil {
+		return AmigaDisk{}, err
+	}
+	const modified, created = 472, 484
+	disk.Volume = adfName(root)
+	disk.Modified = amigaTime(root[modified:])
+	disk.Created = amigaTime(root[created:])
+	visited := map[uint32]bool{key: true}
+	if disk.Files, err = adfFiles(r, root, "", key*2, visited); err != nil {
+		return AmigaDisk{}, err
+	}
+	slices.SortFunc(disk.Files, func(a, b AmigaFile) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return disk, nil
+}
+
+// adfFiles returns the files and directories in the hash table of the directory block.
+// The blocks is the number of blocks on the disk and visited are the keys of the
+// header blocks that were already read, which prevents endless loops on corrupted disks.
+func adfFiles(r io.ReaderAt, dir []byte, path string, blocks uint32, visited map[uint32]bool) ([]AmigaFile, error) {
+	const (
+		hashTable = 24
+		hashSize  = 72
+		byteSize  = 324
+		comment   = 328
+		maxNote   = 79
+		modified  = 420
+		hashChain = 496
+		secType   = 508
+		userDir   = 2
+		file      = 0xfffffffd // file is the secondary type -3
+	)
+	be := binary.BigEndian
+	var files []AmigaFile
+	for i := range hashSize {
+		// the headers with the same hash are linked by the hash chain
+		for key := be.Uint32(dir[hashTable+i*4:]); key != 0; {
+			if key >= blocks || visited[key] || len(visited) > maxBlocks {
+				return nil, fmt.Errorf("%w: header block %d", ErrADFHeader, key)
+			}
+			visited[key] = true
+			p, err := adfBlock(r, key)
+			if err != nil {
+				return nil, err
+			}
+			name := path + adfName(p)
+			switch be.Uint32(p[secType:]) {
+			case userDir:
+				files = append(files, AmigaFile{Name: name, Dir: true, Modified: amigaTime(p[modified:])})
+				sub, err := adfFiles(r, p, name+"/", blocks, visited)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, sub...)
+			case file:
+				files = append(files, AmigaFile{
+					Name:     name,
+					Size:     int64(be.Uint32(p[byteSize:])),
+					Comment:  amigaLatin(p[comment+1 : comment+1+min(int(p[comment]), maxNote)]),
+					Modified: amigaTime(p[modified:]),
+				})
+			}
+			key = be.Uint32(p[hashChain:])
+		}
+	}
+	return files, nil
+}
+
+// adfBlock reads the header block of the key and confirms its type and checksum.
+func adfBlock(r io.ReaderAt, key uint32) ([]byte, error) {
+	p := make([]byte, adfBlockLen)
+	if _, err := r.ReadAt(p, int64(key)*adfBlockLen); err != nil {
+		return nil, fmt.Errorf("%w: read block %d", ErrADFHeader, key)
+	}
+	const header = 2
+	if binary.BigEndian.Uint32(p) != header || !adfChecksum(p) {
+		return nil, fmt.Errorf("%w: header block %d", ErrADFHeader, key)
+	}
+	return p, nil
+}
+
+// adfRoot returns the key of the root block of a double or high density disk.
+func adfRoot(r io.ReaderAt) (uint32, bool) {
+	const header, hashSize, root = 2, 72, 1
+	be := binary.BigEndian
+	p := make([]byte, adfBlockLen)
+	for _, key := range []uint32{adfDDRoot, adfHDRoot} {
+		if _, err := r.ReadAt(p, int64(key)*adfBlockLen); err != nil {
+			return 0, false
+		}
+		if be.Uint32(p) == header && be.Uint32(p[12:]) == hashSize &&
+			be.Uint32(p[adfBlockLen-4:]) == root && adfChecksum(p) {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+// adfDOS returns true if the boot block starts with the DOS dostype of a known file system.
+func adfDOS(boot []byte) bool {
+	const maxFlags = 7
+	return bytes.HasPrefix(boot, []byte("DOS")) && len(boot) > 3 && boot[3] <= maxFlags
+}
+
+// adfBootable returns true if the boot block checksum is valid, which is required by
+// the Kickstart ROM to run the boot code. The checksum is the sum of the longwords
+// that adds the carry, which must be all bits set.
+func adfBootable(boot []byte) bool {
+	if len(boot) < adfBootLen {
+		return false
+	}
+	var sum uint32
+	for i := 0; i < adfBootLen; i += 4 {
+		prev := sum
+		sum += binary.BigEndian.Uint32(boot[i:])
+		if sum < prev {
+			sum++
+		}
+	}
+	return sum == 0xffffffff
+}
+
+// adfChecksum returns true if the sum of the longwords of the block is zero.
+func adfChecksum(p []byte) bool {
+	var sum uint32
+	for i := 0; i+4 <= len(p); i += 4 {
+		sum += binary.BigEndian.Uint32(p[i:])
+	}
+	return sum == 0
+}
+
+// adfName returns the name of the header block, which is a BCPL string of up to 30 characters.
+func adfName(p []byte) string {
+	const name, maxName = 432, 30
+	return amigaLatin(p[name+1 : name+1+min(int(p[name]), maxName)])
+}
+
+// amigaLatin decodes the ISO 8859-1 encoded bytes that are used by the Amiga.
+func amigaLatin(b []byte) string {
+	s, err := charmap.ISO8859_1.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(s)
+}
+
+// amigaTime returns the date of the days since the start of 1978,
+// the minutes past midnight and the ticks, which are 1/50 of a second.
+// It returns zero when all the values are 0.
+func amigaTime(p []byte) time.Time {
+	be := binary.BigEndian
+	days, mins, ticks := be.Uint32(p), be.Uint32(p[4:]), be.Uint32(p[8:])
+	if days == 0 && mins == 0 && ticks == 0 {
+		return time.Time{}
+	}
+	const ticksPerSecond = 50
+	return time.Date(1978, time.January, 1, 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, int(days)).
+		Add(time.Duration(mins)*time.Minute + time.Duration(ticks)*time.Second/ticksPerSecond)
+}
+
+// amigaCRC returns the CRC-16/ARC checksum that is used by the DMS Disk Masher.
+func amigaCRC(p []byte) uint16 {
+	const poly = 0xa001
+	crc := uint16(0)
+	for _, b := range p {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ poly
+				continue
+			}
+			crc >>= 1
+		}
+	}
+	return crc
+}
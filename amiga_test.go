@@ -0,0 +1,256 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+const (
+	adfDD    = 1760 * 512
+	adfRoot  = 880
+	adfBlock = 512
+)
+
+// adfBoot sets the boot block checksum, which is the sum of the longwords that adds the carry.
+func adfBoot(b []byte) {
+	binary.BigEndian.PutUint32(b[4:], 0)
+	var sum uint32
+	for i := 0; i < 1024; i += 4 {
+		prev := sum
+		sum += binary.BigEndian.Uint32(b[i:])
+		if sum < prev {
+			sum++
+		}
+	}
+	binary.BigEndian.PutUint32(b[4:], ^sum)
+}
+
+// adfHeader writes a header block at the key and sets its checksum.
+func adfHeader(b []byte, key int, secType uint32, name string, fn func(p []byte)) {
+	p := b[key*adfBlock : (key+1)*adfBlock]
+	binary.BigEndian.PutUint32(p, 2)
+	binary.BigEndian.PutUint32(p[508:], secType)
+	p[432] = byte(len(name))
+	copy(p[433:], name)
+	if fn != nil {
+		fn(p)
+	}
+	binary.BigEndian.PutUint32(p[20:], 0)
+	var sum uint32
+	for i := 0; i < adfBlock; i += 4 {
+		sum += binary.BigEndian.Uint32(p[i:])
+	}
+	binary.BigEndian.PutUint32(p[20:], -sum)
+}
+
+// amigaDays returns the days since the start of 1978.
+func amigaDays(t time.Time) uint32 {
+	return uint32(t.Sub(time.Date(1978, time.January, 1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// adfImage returns a double density FFS floppy image with a file that is linked by a hash chain,
+// a directory and a file in the directory.
+func adfImage() []byte {
+	b := make([]byte, adfDD)
+	copy(b, "DOS\x01")
+	binary.BigEndian.PutUint32(b[8:], adfRoot)
+	copy(b[12:], []byte{0x43, 0xfa, 0x00, 0x3e, 0x70, 0x25, 0x4e, 0xae})
+	adfBoot(b)
+	be := binary.BigEndian
+	day := amigaDays(time.Date(1993, time.April, 1, 0, 0, 0, 0, time.UTC))
+	const readme, sDir, cDir, dir = 882, 883, 884, 885
+	adfHeader(b, adfRoot, 1, "Demo Disk", func(p []byte) {
+		be.PutUint32(p[12:], 72)
+		be.PutUint32(p[24+5*4:], readme)
+		be.PutUint32(p[24+10*4:], cDir)
+		be.PutUint32(p[472:], day)
+		be.PutUint32(p[484:], day)
+		be.PutUint32(p[488:], 90)
+		be.PutUint32(p[492:], 100)
+	})
+	adfHeader(b, readme, 0xfffffffd, "Read Me", func(p []byte) {
+		be.PutUint32(p[4:], readme)
+		be.PutUint32(p[324:], 1234)
+		p[328] = 8
+		copy(p[329:], "Greets!\xa9")
+		be.PutUint32(p[420:], day)
+		be.PutUint32(p[496:], sDir)
+		be.PutUint32(p[500:], adfRoot)
+	})
+	adfHeader(b, sDir, 2, "s", func(p []byte) {
+		be.PutUint32(p[4:], sDir)
+		be.PutUint32(p[500:], adfRoot)
+	})
+	adfHeader(b, cDir, 2, "c", func(p []byte) {
+		be.PutUint32(p[4:], cDir)
+		be.PutUint32(p[24+3*4:], dir)
+		be.PutUint32(p[500:], adfRoot)
+	})
+	adfHeader(b, dir, 0xfffffffd, "Dir", func(p []byte) {
+		be.PutUint32(p[4:], dir)
+		be.PutUint32(p[324:], 5432)
+		be.PutUint32(p[500:], cDir)
+	})
+	return b
+}
+
+func TestADFHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestADFHeaders")
+	_, err := magicnumber.ADFHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	b := adfImage()
+	r := bytes.NewReader(b)
+	be.Equal(t, magicnumber.AmigaADF, magicnumber.Find(r))
+	sign, err := magicnumber.DiscImage(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.AmigaADF, sign)
+	be.Equal(t, magicnumber.DiscImageCategory, magicnumber.AmigaADF.Category())
+	valid, sign, err := magicnumber.MatchExt("DEMO.ADF", r)
+	be.Err(t, err, nil)
+	be.True(t, valid)
+	be.Equal(t, magicnumber.AmigaADF, sign)
+	disk, err := magicnumber.ADFHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, "FFS", disk.FileSystem)
+	be.True(t, disk.Bootable)
+	be.True(t, !disk.International)
+	be.True(t, !disk.DirCache)
+	be.True(t, !disk.HighDensity)
+	be.Equal(t, "Demo Disk", disk.Volume)
+	be.Equal(t, time.Date(1993, time.April, 1, 1, 30, 2, 0, time.UTC), disk.Created)
+	be.Equal(t, time.Date(1993, time.April, 1, 0, 0, 0, 0, time.UTC), disk.Modified)
+	names := make([]string, 0, len(disk.Files))
+	for _, file := range disk.Files {
+		names = append(names, file.Name)
+	}
+	be.Equal(t, []string{"Read Me", "c", "c/Dir", "s"}, names)
+	be.Equal(t, int64(1234), disk.Files[0].Size)
+	be.Equal(t, "Greets!©", disk.Files[0].Comment)
+	be.Equal(t, time.Date(1993, time.April, 1, 0, 0, 0, 0, time.UTC), disk.Files[0].Modified)
+	be.True(t, disk.Files[1].Dir)
+	be.Equal(t, int64(5432), disk.Files[2].Size)
+	be.True(t, !disk.Files[2].Dir)
+
+	// a hash chain that links back to itself is an endless loop
+	loop := bytes.Clone(b)
+	adfHeader(loop, 883, 2, "s", func(p []byte) {
+		binary.BigEndian.PutUint32(p[496:], 882)
+	})
+	_, err = magicnumber.ADFHeaders(bytes.NewReader(loop))
+	be.Err(t, err, magicnumber.ErrADFHeader)
+	// a header block with a bad checksum
+	bad := bytes.Clone(b)
+	bad[885*adfBlock+433] = 'd'
+	_, err = magicnumber.ADFHeaders(bytes.NewReader(bad))
+	be.Err(t, err, magicnumber.ErrADFHeader)
+}
+
+func TestADFNonDOS(t *testing.T) {
+	t.Parallel()
+	t.Log("TestADFNonDOS")
+	// a demo disk with its own track loader has a bootable boot block without a file system
+	b := make([]byte, adfDD)
+	copy(b, "TRAK")
+	copy(b[12:], []byte{0x41, 0xfa, 0x00, 0x10, 0x4e, 0xd0})
+	adfBoot(b)
+	r := bytes.NewReader(b)
+	be.Equal(t, magicnumber.AmigaADF, magicnumber.Find(r))
+	disk, err := magicnumber.ADFHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, "NDOS", disk.FileSystem)
+	be.True(t, disk.Bootable)
+	be.Equal(t, "", disk.Volume)
+	be.Equal(t, 0, len(disk.Files))
+	// a boot block with a bad checksum or a file that is not the size of a floppy disk
+	b[100] = 1
+	be.True(t, !magicnumber.Adf(bytes.NewReader(b)))
+	b[100] = 0
+	be.True(t, !magicnumber.Adf(bytes.NewReader(b[:adfDD-adfBlock])))
+	_, err = magicnumber.ADFHeaders(bytes.NewReader(b[:adfDD-adfBlock]))
+	be.Err(t, err, magicnumber.ErrADFHeader)
+}
+
+// arcCRC returns the CRC-16/ARC checksum of the DMS info header.
+func arcCRC(p []byte) uint16 {
+	crc := uint16(0)
+	for _, b := range p {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xa001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// lzxArchive returns the info header and the first entry header of an LZX archive.
+func lzxArchive(name, comment string) []byte {
+	b := []byte("LZX\x00\x00\x0a\x04\x0a\x00\x00")
+	entry := make([]byte, 31)
+	binary.LittleEndian.PutUint32(entry[2:], 1024)
+	binary.LittleEndian.PutUint32(entry[6:], 512)
+	entry[11] = 2
+	entry[14] = byte(len(comment))
+	entry[30] = byte(len(name))
+	sum := crc32.NewIEEE()
+	_, _ = sum.Write(entry)
+	_, _ = sum.Write([]byte(name + comment))
+	binary.LittleEndian.PutUint32(entry[26:], sum.Sum32())
+	b = append(b, entry...)
+	return append(b, name+comment...)
+}
+
+func TestAmigaArchives(t *testing.T) {
+	t.Parallel()
+	t.Log("TestAmigaArchives")
+	dms := []byte("DMS!")
+	info := make([]byte, 50)
+	binary.BigEndian.PutUint16(info[14:], 79)
+	binary.BigEndian.PutUint16(info[46:], 111)
+	dms = append(dms, info...)
+	dms = binary.BigEndian.AppendUint16(dms, arcCRC(info))
+	pp := []byte("PP20\x09\x0a\x0c\x0d\x00\x00")
+	tests := []struct {
+		name string
+		b    []byte
+		sign magicnumber.Signature
+	}{
+		{"LZX", lzxArchive("ReadMe.txt", "greetings"), magicnumber.AmigaLZX},
+		{"LZX no comment", lzxArchive("demo.exe", ""), magicnumber.AmigaLZX},
+		{"DMS", dms, magicnumber.AmigaDMS},
+		{"PowerPacker", pp, magicnumber.PowerPacker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := bytes.NewReader(tt.b)
+			be.Equal(t, tt.sign, magicnumber.Find(r))
+			sign, err := magicnumber.Archive(r)
+			be.Err(t, err, nil)
+			be.Equal(t, tt.sign, sign)
+		})
+	}
+	be.True(t, slices.Contains(magicnumber.ArchivesBBS(), magicnumber.AmigaLZX))
+	be.True(t, slices.Contains(magicnumber.ArchivesBBS(), magicnumber.AmigaDMS))
+	// a bad checksum or an unknown compression efficiency
+	lzx := lzxArchive("ReadMe.txt", "")
+	lzx[len(lzx)-1] = 'T'
+	be.True(t, !magicnumber.Lzx(bytes.NewReader(lzx)))
+	be.True(t, !magicnumber.Lzx(strings.NewReader("LZX is the best cruncher on the Amiga, greetings to all")))
+	bad := bytes.Clone(dms)
+	bad[20] = 1
+	be.True(t, !magicnumber.Dms(bytes.NewReader(bad)))
+	be.True(t, !magicnumber.PowerPack(strings.NewReader("PP20\x09\x09\x09\x0a\x00\x00")))
+}
@@ -0,0 +1,178 @@
+package magicnumber
+
+// Package file arc.go contains the functions that parse the entries of the ARC format
+// by System Enhancement Associates and the PAK format by NoGate Consulting.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrArcHeader = errors.New("arc header is invalid")
+
+// ArcEntry is a file entry of an ARC or PAK archive.
+type ArcEntry struct {
+	Name           string    // Name is the path of the file using slash separators, decoded from CP437
+	Modified       time.Time // Modified is the date and time the file was last modified
+	Offset         int64     // Offset is the position of the compressed data
+	CompressedSize int64     // CompressedSize is the size of the compressed data
+	OriginalSize   int64     // OriginalSize is the size of the file when uncompressed
+	CRC16          uint16    // CRC16 is the checksum of the uncompressed file
+	Method         ArcMethod // Method is the compression method
+}
+
+// IsDir returns true if the entry is an ARC 6 subdirectory.
+func (e ArcEntry) IsDir() bool {
+	return e.Method == ArcDirectory
+}
+
+// ArcMethod is the compression method of an ARC or PAK file entry.
+type ArcMethod byte
+
+const (
+	ArcStoredOld ArcMethod = 1  // ArcStoredOld is the stored method of ARC 1 with a shorter header
+	ArcStored    ArcMethod = 2  // ArcStored is the uncompressed method
+	ArcPacked    ArcMethod = 3  // ArcPacked is run-length encoding
+	ArcSqueezed  ArcMethod = 4  // ArcSqueezed is run-length encoding with Huffman encoding
+	ArcCrunched  ArcMethod = 8  // ArcCrunched is run-length encoding with dynamic LZW, methods 5 to 7 are older variants
+	ArcSquashed  ArcMethod = 9  // ArcSquashed is the 13-bit LZW of PKARC and PKPAK
+	ArcCrushed   ArcMethod = 10 // ArcCrushed is the run-length encoding with LZW of PAK
+	ArcDistilled ArcMethod = 11 // ArcDistilled is the LZ77 with static Huffman encoding of PAK
+	ArcDirectory ArcMethod = 30 // ArcDirectory is an ARC 6 subdirectory that contains the entries of the directory
+)
+
+// String returns the name of the compression method.
+func (m ArcMethod) String() string {
+	switch m {
+	case ArcStoredOld, ArcStored:
+		return "stored"
+	case ArcPacked:
+		return "packed"
+	case ArcSqueezed:
+		return "squeezed"
+	case 5, 6, 7, ArcCrunched:
+		return "crunched"
+	case ArcSquashed:
+		return "squashed"
+	case ArcCrushed:
+		return "crushed"
+	case ArcDistilled:
+		return "distilled"
+	case ArcDirectory:
+		return "directory"
+	}
+	return fmt.Sprintf("unknown method %d", int(m))
+}
+
+// ArcEntries walks the entry chain of an ARC or PAK archive and returns the file entries
+// in the stored order. The whole chain is validated, so an error is returned for files
+// that only share the 0x1A marker byte with the ARC format.
+//
+// The entries of ARC 6 subdirectories are listed after the directory entry,
+// and the information records of ARC 6 are skipped.
+func ArcEntries(r io.ReaderAt) ([]ArcEntry, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	entries, _, err := arcWalk(r, 0, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// arcWalk reads the entries starting at the offset until the end of archive marker,
+// and returns the entries and the offset that follows the marker.
+// The dir is the path prefix of entries within a subdirectory and depth is the number of parent directories.
+func arcWalk(r io.ReaderAt, offset int64, dir string, depth int) ([]ArcEntry, int64, error) {
+	const (
+		marker     = 0x1a
+		endMethod  = 0
+		endDir     = 31
+		headerLen  = 29
+		oldLen     = 25
+		nameLen    = 13
+		infoFirst  = 20
+		infoLast   = 29
+		maxMethods = ArcDistilled
+		maxDepth   = 32
+	)
+	if depth > maxDepth {
+		return nil, 0, fmt.Errorf("%w: too many subdirectories at %d", ErrArcHeader, offset)
+	}
+	entries := []ArcEntry{}
+	for range maxBlocks {
+		p := make([]byte, headerLen)
+		n, _ := r.ReadAt(p, offset)
+		if n < 2 || p[0] != marker {
+			return nil, 0, fmt.Errorf("%w: no marker at %d", ErrArcHeader, offset)
+		}
+		method := ArcMethod(p[1])
+		switch {
+		case method == endMethod, method == endDir && dir != "":
+			return entries, offset + 2, nil
+		case method == ArcStoredOld && n < oldLen,
+			method != ArcStoredOld && n < headerLen:
+			return nil, 0, fmt.Errorf("%w: short header at %d", ErrArcHeader, offset)
+		case method > maxMethods && method != ArcDirectory &&
+			(method < infoFirst || method > infoLast):
+			return nil, 0, fmt.Errorf("%w: %s at %d", ErrArcHeader, method, offset)
+		}
+		name, _, _ := bytes.Cut(p[2:2+nameLen], []byte{0})
+		if len(name) == 0 && method != ArcDirectory && (method < infoFirst || method > infoLast) {
+			return nil, 0, fmt.Errorf("%w: no filename at %d", ErrArcHeader, offset)
+		}
+		le := binary.LittleEndian
+		entry := ArcEntry{
+			Name:           dir + DecodeCP437(name),
+			Method:         method,
+			Modified:       DosTime(le.Uint16(p[19:]), le.Uint16(p[21:])),
+			CRC16:          le.Uint16(p[23:]),
+			Offset:         offset + headerLen,
+			CompressedSize: int64(le.Uint32(p[15:])),
+		}
+		entry.OriginalSize = entry.CompressedSize
+		if method == ArcStoredOld {
+			entry.Offset = offset + oldLen
+		} else {
+			entry.OriginalSize = int64(le.Uint32(p[25:]))
+		}
+		offset = entry.Offset + entry.CompressedSize
+		switch {
+		case method >= infoFirst && method <= infoLast:
+			continue
+		case method == ArcDirectory:
+			entries = append(entries, entry)
+			nested, end, err := arcWalk(r, entry.Offset, entry.Name+"/", depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			entries = append(entries, nested...)
+			// the walk continues after the nested entries when the directory size does not cover them
+			offset = max(offset, end)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return nil, 0, fmt.Errorf("%w: too many entries", ErrArcHeader)
+}
+
+// arcPak returns true if the ARC entries use the crushed or distilled methods of PAK,
+// or the end of archive marker is followed by the 0xFE extended records of PAK.
+func arcPak(r io.ReaderAt, entries []ArcEntry, end int64) bool {
+	for _, entry := range entries {
+		if entry.Method == ArcCrushed || entry.Method == ArcDistilled {
+			return true
+		}
+	}
+	const extended = 0xfe
+	p := make([]byte, 1)
+	if n, _ := r.ReadAt(p, end); n == 1 && p[0] == extended {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestArcEntries(t *testing.T) {
+	t.Parallel()
+	t.Log("TestArcEntries")
+	_, err := magicnumber.ArcEntries(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(seaFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	entries, err := magicnumber.ArcEntries(r)
+	be.Err(t, err, nil)
+	be.Equal(t, 15, len(entries))
+	ans := entries[0]
+	be.Equal(t, "TEST.ANS", ans.Name)
+	be.Equal(t, "crunched", ans.Method.String())
+	be.Equal(t, int64(29), ans.Offset)
+	be.Equal(t, int64(64), ans.CompressedSize)
+	be.Equal(t, int64(68), ans.OriginalSize)
+	be.Equal(t, uint16(15632), ans.CRC16)
+	be.Equal(t, time.Date(2012, time.September, 19, 14, 21, 52, 0, time.UTC), ans.Modified)
+	be.Equal(t, magicnumber.ArcStored, entries[1].Method)
+	be.Equal(t, magicnumber.ArcPacked, entries[7].Method)
+	be.Equal(t, "TEST.TXT", entries[14].Name)
+
+	p, err := os.Open(tdfile(pakFile))
+	be.Err(t, err, nil)
+	defer p.Close()
+	entries, err = magicnumber.ArcEntries(p)
+	be.Err(t, err, nil)
+	be.Equal(t, 3, len(entries))
+	for _, entry := range entries {
+		be.Equal(t, "crushed", entry.Method.String())
+	}
+	be.Equal(t, "TESTDAT3.TXT", entries[2].Name)
+	be.Equal(t, int64(81410), entries[2].OriginalSize)
+}
+
+func TestArcMethod(t *testing.T) {
+	t.Parallel()
+	t.Log("TestArcMethod")
+	be.Equal(t, "stored", magicnumber.ArcStoredOld.String())
+	be.Equal(t, "squeezed", magicnumber.ArcSqueezed.String())
+	be.Equal(t, "crunched", magicnumber.ArcMethod(5).String())
+	be.Equal(t, "squashed", magicnumber.ArcSquashed.String())
+	be.Equal(t, "distilled", magicnumber.ArcDistilled.String())
+	be.Equal(t, "unknown method 18", magicnumber.ArcMethod(18).String())
+}
+
+func TestArcInvalid(t *testing.T) {
+	t.Parallel()
+	t.Log("TestArcInvalid")
+	// a binary that starts with the ARC marker and a valid method, but is not an archive
+	junk := append([]byte{0x1a, 0x02}, bytes.Repeat([]byte{0xff}, 64)...)
+	_, err := magicnumber.ArcEntries(bytes.NewReader(junk))
+	be.Err(t, err, magicnumber.ErrArcHeader)
+	be.True(t, !magicnumber.ArcSEA(bytes.NewReader(junk)))
+	be.True(t, !magicnumber.Pak(bytes.NewReader(junk)))
+
+	// a stored entry followed by the end of archive marker and the PAK extended records
+	arc := []byte{0x1a, 0x02, 'A', '.', 'T', 'X', 'T', 0, 0, 0, 0, 0, 0, 0, 0}
+	arc = append(arc, 2, 0, 0, 0, 0x33, 0x41, 0x00, 0x70, 0, 0, 2, 0, 0, 0)
+	arc = append(arc, "hi"...)
+	arc = append(arc, 0x1a, 0x00)
+	be.True(t, magicnumber.ArcSEA(bytes.NewReader(arc)))
+	be.True(t, !magicnumber.Pak(bytes.NewReader(arc)))
+	arc = append(arc, 0xfe, 0x00)
+	be.True(t, !magicnumber.ArcSEA(bytes.NewReader(arc)))
+	be.True(t, magicnumber.Pak(bytes.NewReader(arc)))
+}
+
+func TestArcDirectories(t *testing.T) {
+	t.Parallel()
+	t.Log("TestArcDirectories")
+	// chain returns the nested ARC 6 subdirectories with a size of 0,
+	// which are each ended by the end of directory marker
+	chain := func(depth int) []byte {
+		dir := make([]byte, 29)
+		dir[0], dir[1] = 0x1a, 0x1e
+		var arc []byte
+		for range depth {
+			arc = append(arc, dir...)
+		}
+		for range depth {
+			arc = append(arc, 0x1a, 0x1f)
+		}
+		return append(arc, 0x1a, 0x00)
+	}
+	entries, err := magicnumber.ArcEntries(bytes.NewReader(chain(30)))
+	be.Err(t, err, nil)
+	be.Equal(t, 30, len(entries))
+	for _, entry := range entries {
+		be.Equal(t, magicnumber.ArcDirectory, entry.Method)
+	}
+	_, err = magicnumber.ArcEntries(bytes.NewReader(chain(1000)))
+	be.Err(t, err, magicnumber.ErrArcHeader)
+}
@@ -5,7 +5,10 @@ package magicnumber
 
 import (
 	"bytes"
+	"encoding/binary"
+	"hash/crc32"
 	"io"
+	"slices"
 )
 
 //
@@ -54,7 +57,7 @@ func PkReduce(r io.ReaderAt) bool {
 // PkShrink matches the PKWARE Shrink method zip archive format.
 // This is a legacy method and is generally not supported in modern ZIP tools and libraries.
 func PkShrink(r io.ReaderAt) bool {
-	return pkzip(r) == pkSkrink
+	return pkzip(r) == pkShrink
 }
 
 // PkzipMulti matches the PKWARE Multi-Volume Zip archive format.
@@ -73,20 +76,51 @@ type pkComp int
 const (
 	pkNone pkComp = iota
 	pkZip
-	pkSkrink
+	pkShrink
 	pkReduce
 	pkImplode
 )
 
+// pkClass is the zip compression method of a reader, which is classified once when
+// the PkShrink, PkReduce, PkImplode and Pkzip signatures are tried in turn,
+// as each one parses the central directory.
+type pkClass struct {
+	comp pkComp
+	done bool
+}
+
+// match returns true for ok when the signature is one of the zip compression methods,
+// and found is true when the reader uses that method.
+func (z *pkClass) match(r io.ReaderAt, sign Signature) (found, ok bool) {
+	var want pkComp
+	switch sign {
+	case PKWAREZipShrink:
+		want = pkShrink
+	case PKWAREZipReduce:
+		want = pkReduce
+	case PKWAREZipImplode:
+		want = pkImplode
+	case PKWAREZip:
+		want = pkZip
+	default:
+		return false, false
+	}
+	if !z.done {
+		z.comp, z.done = pkzip(r), true
+	}
+	return z.comp == want, true
+}
+
 // pkzip matches the PKWARE Zip archive format.
 // This is the most common ZIP format and is widely supported and has been
 // tested against many discontinued and legacy ZIP methods and packagers.
 //
 // Due to the complex history of the ZIP format, 4 possible return values
-// maybe returned.
+// maybe returned. The compression methods of every entry in the central directory are
+// used when it can be read, otherwise only the method of the first local file header is used.
 //   - pkNone is returned if the file is not a ZIP archive.
 //   - pkOkay is returned if the file is a ZIP archive, except for the compression methods below.
-//   - pkSkrink is returned if the ZIP archive uses the PKWARE shrink method, found in PKZIP v0.9.
+//   - pkShrink is returned if the ZIP archive uses the PKWARE shrink method, found in PKZIP v0.9.
 //   - pkReduce is returned if the ZIP archive uses the PKWARE reduction method, found in PKZIP v0.8.
 //   - pkImplode is returned if the ZIP archive uses the PKWARE implode method, found in PKZIP v1.01.
 //
@@ -99,16 +133,13 @@ func pkzip(r io.ReaderAt) pkComp {
 	if n, err := sr.Read(p); err != nil || n < size {
 		return pkNone
 	}
-	if len(p) < size {
-		return pkNone
-	}
 	// local file header signature     4 bytes  (0x04034b50)
 	localFileHeader := []byte{'P', 'K', 0x3, 0x4}
 	if !bytes.Equal(p[:4], localFileHeader) {
 		return pkNone // 50 4b 03 04
 	}
 	// version needed to extract       2 bytes
-	versionNeeded := p[4] + p[5]
+	versionNeeded := binary.LittleEndian.Uint16(p[4:])
 	if versionNeeded == 0 {
 		// legacy versions of PKZIP returned either 0x.0a (10) or 0x14 (20).
 		return pkNone // 0a 00
@@ -117,11 +148,16 @@ func pkzip(r io.ReaderAt) pkComp {
 	// skip this as there's too many reserved values that might cause false positive rejections
 	//
 	// compression method              2 bytes
-	compresionMethod := p[8] + p[9]
+	compresionMethod := binary.LittleEndian.Uint16(p[8:])
+	// the first entry is not representative of the archive,
+	// so use the methods of every entry listed in the central directory
+	if entries, err := ZipEntries(r); err == nil && len(entries) > 0 {
+		return zipMethods(entries)
+	}
 	return pkMethod(compresionMethod)
 }
 
-func pkMethod(compresionMethod byte) pkComp {
+func pkMethod(compresionMethod uint16) pkComp {
 	const (
 		store       = 0x0
 		shrink      = 0x1
@@ -150,7 +186,7 @@ func pkMethod(compresionMethod byte) pkComp {
 	case store, deflate, deflate64:
 		return pkZip
 	case shrink:
-		return pkSkrink
+		return pkShrink
 	case reduce1, reduce2, reduce3, reduce4:
 		return pkReduce
 	case implode:
@@ -162,16 +198,16 @@ func pkMethod(compresionMethod byte) pkComp {
 	}
 }
 
-// Tar matches the Tape ARchive format.
+// Tar matches the Tape ARchive format, including the pre-POSIX V7 format that has no magic value,
+// by validating the checksum of the first header.
 func Tar(r io.ReaderAt) bool {
-	const offset = 257
-	const size = 5
+	const size = tarBlock
 	p := make([]byte, size)
-	sr := io.NewSectionReader(r, offset, size)
+	sr := io.NewSectionReader(r, 0, size)
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	return bytes.Equal(p, []byte{'u', 's', 't', 'a', 'r'})
+	return tarChecksum(p)
 }
 
 // Rar matches the Roshal ARchive format.
@@ -196,46 +232,51 @@ func Rarv5(r io.ReaderAt) bool {
 	return bytes.Equal(p, []byte{'R', 'a', 'r', 0x21, 0x1a, 0x7, 0x1, 0x0})
 }
 
-// Gzip matches the Gzip Compress archive format.
+// Gzip matches the Gzip Compress archive format,
+// which uses the deflate method and has no reserved header flags set.
 func Gzip(r io.ReaderAt) bool {
-	const size = 3
+	const size = 4
 	p := make([]byte, size)
 	sr := io.NewSectionReader(r, 0, size)
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	if bytes.Equal(p, []byte{0x1f, 0x8b, 0x08}) {
-		return true
-	}
-	const offset = 512
-	p = make([]byte, size)
-	sr = io.NewSectionReader(r, offset, size)
-	if n, err := sr.Read(p); err != nil || n < size {
-		return false
-	}
-	return bytes.Equal(p, []byte{0x1f, 0x8b, 0x08})
+	const reserved = 0xe0
+	return bytes.Equal(p[:3], []byte{0x1f, 0x8b, 0x08}) && p[3]&reserved == 0
 }
 
-// Bzip2 matches the Bzip2 Compress archive format.
+// Bzip2 matches the Bzip2 Compress archive format,
+// which has a block size level and is followed by a compressed block or the end of stream marker.
 func Bzip2(r io.ReaderAt) bool {
-	const size = 3
+	const size = 10
 	p := make([]byte, size)
 	sr := io.NewSectionReader(r, 0, size)
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	return bytes.Equal(p, []byte{'B', 'Z', 'h'})
+	if !bytes.Equal(p[:3], []byte{'B', 'Z', 'h'}) || p[3] < '1' || p[3] > '9' {
+		return false
+	}
+	magic := uint64(0)
+	for _, b := range p[4:] {
+		magic = magic<<8 | uint64(b)
+	}
+	return magic == bzip2Block || magic == bzip2End
 }
 
 // X7z matches the 7z Compress archive format.
 func X7z(r io.ReaderAt) bool {
-	const size = 6
+	const size = 32
 	p := make([]byte, size)
 	sr := io.NewSectionReader(r, 0, size)
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	return bytes.Equal(p, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c})
+	if !bytes.Equal(p[:6], []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}) {
+		return false
+	}
+	// the signature is followed by the version and the checksum of the start header
+	return crc32.ChecksumIEEE(p[12:]) == binary.LittleEndian.Uint32(p[8:])
 }
 
 // XZ matches the XZ Compress archive format.
@@ -249,7 +290,8 @@ func XZ(r io.ReaderAt) bool {
 	return bytes.Equal(p, []byte{0xfd, '7', 'z', 'X', 'Z', 0x0})
 }
 
-// ZStd matches the ZStandard archive format.
+// ZStd matches the ZStandard archive format,
+// which includes files that start with a skippable frame and the Zstandard dictionaries.
 func ZStd(r io.ReaderAt) bool {
 	const size = 4
 	p := make([]byte, size)
@@ -257,7 +299,8 @@ func ZStd(r io.ReaderAt) bool {
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	return bytes.Equal(p, []byte{0x28, 0xb5, 0x2f, 0xfd})
+	magic := binary.LittleEndian.Uint32(p)
+	return magic == zstdFrame || magic&^0x0f == zstdSkippable || magic == zstdDictionary
 }
 
 // ArcFree matches the FreeArc compression format.
@@ -272,44 +315,45 @@ func ArcFree(r io.ReaderAt) bool {
 }
 
 // ArcSEA matches the ARChive SEA compression format.
+// The whole entry chain must be valid and archives that use the methods
+// or the extended records of NoGate PAK are not matched.
 func ArcSEA(r io.ReaderAt) bool {
-	const size = 2
-	p := make([]byte, size)
-	sr := io.NewSectionReader(r, 0, size)
-	if n, err := sr.Read(p); err != nil || n < size {
+	entries, end, err := arcWalk(r, 0, "", 0)
+	if err != nil || len(entries) == 0 {
 		return false
 	}
-	const (
-		id     = 0x1a
-		method = 0x11 // max method id for ARC compression format
-	)
-	if len(p) < size {
-		return false
-	}
-	return p[0] == id && p[1] <= method
+	return !arcPak(r, entries, end)
 }
 
-// LzhLha matches the LHA and LZH compression formats.
+// LzhLha matches the LHA and LZH compression formats, including the LArc -lz?- and the PMarc -pm?- methods.
 func LzhLha(r io.ReaderAt) bool {
-	const offset = 2
-	const size = 3
+	const size = 21
 	p := make([]byte, size)
-	sr := io.NewSectionReader(r, offset, size)
+	sr := io.NewSectionReader(r, 0, size)
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	return bytes.Equal(p, []byte{'-', 'l', 'h'})
+	const methodStart, methodEnd, level, maxLevel = 2, 7, 20, 3
+	if p[level] > maxLevel {
+		return false
+	}
+	return slices.Contains(lhaMethods(), string(p[methodStart:methodEnd]))
 }
 
 // Zoo matches the Zoo compression format.
 func Zoo(r io.ReaderAt) bool {
-	const size = 4
+	const size = 32
 	p := make([]byte, size)
 	sr := io.NewSectionReader(r, 0, size)
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	return bytes.Equal(p, []byte{'Z', 'O', 'O', 0x20})
+	if !bytes.Equal(p[:4], []byte{'Z', 'O', 'O', 0x20}) {
+		return false
+	}
+	// the header text is followed by the tag and the offset of the first entry with its negation
+	le := binary.LittleEndian
+	return le.Uint32(p[20:]) == zooTag && le.Uint32(p[24:])+le.Uint32(p[28:]) == 0
 }
 
 // Arj matches ARJ compression format.
@@ -328,62 +372,396 @@ func Arj(r io.ReaderAt) bool {
 	if len(p) < size {
 		return false
 	}
+	// the basic header size of the main header is between 16 and 2600 bytes
+	const minBasic, maxBasic = 16, 2600
+	if basic := binary.LittleEndian.Uint16(p[2:]); basic < minBasic || basic > maxBasic {
+		return false
+	}
 	return p[0] == id && p[1] == signature && p[10] == offset
 }
 
 // Cab matches the Microsoft CABinet archive format.
 func Cab(r io.ReaderAt) bool {
-	const size = 4
+	const size = 26
 	p := make([]byte, size)
 	sr := io.NewSectionReader(r, 0, size)
 	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
-	return bytes.Equal(p, []byte{'M', 'S', 'C', 'F'})
+	if !bytes.Equal(p[:4], []byte{'M', 'S', 'C', 'F'}) {
+		return false
+	}
+	// the signature is followed by a reserved zero value and the major version is 1
+	const major = 1
+	return binary.LittleEndian.Uint32(p[4:]) == 0 && p[25] == major
 }
 
-// Pak matches the NoGate Consulting PAK format.
+// Pak matches the NoGate Consulting PAK format, which is an extension of the ARC format.
+// The whole entry chain must be valid and the archive must use the crushed or distilled
+// methods or contain the extended records of PAK.
 func Pak(r io.ReaderAt) bool {
-	size := Length(r)
-	const minimum = 4
-	if size < minimum {
+	entries, end, err := arcWalk(r, 0, "", 0)
+	if err != nil || len(entries) == 0 {
+		return false
+	}
+	return arcPak(r, entries, end)
+}
+
+// Ace matches the ACE archive format by Marcel Lemke,
+// which has the "**ACE**" signature within the main archive header.
+func Ace(r io.ReaderAt) bool {
+	const size = 16
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	const mainHeader = 0
+	return p[4] == mainHeader && bytes.Equal(p[7:14], []byte("**ACE**"))
+}
+
+// Ha matches the HA archive format by Harri Hirvola.
+// The signature is weak, so the method of the first entry and the
+// NUL terminated path and filename that follow its header are also checked.
+func Ha(r io.ReaderAt) bool {
+	const size = 4 + 17 + 512
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	n, _ := sr.Read(p)
+	const headerLen = 4 + 17
+	if n <= headerLen {
+		return false
+	}
+	if p[0] != 'H' || p[1] != 'A' || binary.LittleEndian.Uint16(p[2:]) == 0 {
 		return false
 	}
+	const cpy, asc, hsc, dir, special = 0, 1, 2, 14, 15
+	switch p[4] & 0x0f {
+	case cpy, asc, hsc, dir, special:
+	default:
+		return false
+	}
+	_, rest, found := bytes.Cut(p[headerLen:n], []byte{0})
+	if !found {
+		return false
+	}
+	_, _, found = bytes.Cut(rest, []byte{0})
+	return found
+}
 
-	const headerSize = 2
-	header := make([]byte, headerSize)
-	if _, err := r.ReadAt(header, 0); err != nil {
+// Hyp matches the Hyper archive format, the first entry is either
+// compressed "HP" or stored "ST" and is followed by the version number.
+func Hyp(r io.ReaderAt) bool {
+	const size = 4
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
+	if p[0] != 0x1a || (string(p[1:3]) != "HP" && string(p[1:3]) != "ST") {
+		return false
+	}
+	// the version is binary coded decimal, such as 0x25 for v2.5
+	major, minor := p[3]>>4, p[3]&0x0f
+	return major > 0 && major < 10 && minor < 10
+}
 
-	const arcMarker = 0x1a
-	if header[0] != arcMarker {
+// Sqz matches the Squeeze It archive format.
+func Sqz(r io.ReaderAt) bool {
+	const size = 6
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
 		return false
 	}
+	return bytes.Equal(p[:5], []byte("HLSQZ")) && p[5] >= '0' && p[5] <= '9'
+}
 
-	const crushed = 0x0A   // Method 10 (0x0A) = Crushed (RLE90 + LZW)
-	const distilled = 0x0B // Method 11 (0x0B) = Distilled (LZ77 + Static Huffman)
-	methodNoGate := header[1] == crushed || header[1] == distilled
+// Uc2 matches the UltraCompressor II archive format by Nico de Vries.
+// The file header stores the component length twice, the second copy
+// is offset by a constant that is also checked.
+func Uc2(r io.ReaderAt) bool {
+	const size = 12
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	if !bytes.Equal(p[:4], []byte{'U', 'C', '2', 0x1a}) {
+		return false
+	}
+	const check = 0x01b2c3d4
+	le := binary.LittleEndian
+	return le.Uint32(p[4:])+check == le.Uint32(p[8:])
+}
 
-	const trailerSize = 2
-	trailer := make([]byte, trailerSize)
-	if _, err := r.ReadAt(trailer, size-trailerSize); err != nil {
+// Dwc matches the DWC archive format by Dean W. Cooper.
+// The archive is identified by the trailer at the end of the file, so the reader
+// must implement [io.Seeker].
+func Dwc(r io.ReaderAt) bool {
+	const size = 27
+	length := Length(r)
+	if length < size {
 		return false
 	}
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, length-size, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	// the trailer starts with its own size and the size of a directory entry
+	le := binary.LittleEndian
+	entries := int64(le.Uint32(p[20:]))
+	return bytes.Equal(p[24:], []byte("DWC")) && le.Uint16(p) == size && p[2] > 0 &&
+		entries*int64(p[2]) <= length-size
+}
 
-	const (
-		nul = 0x00
-		arc = 0x1A
-		pak = 0xFE
-	)
-	eofARC := trailer[0] == arc && trailer[1] == nul
-	eofPAK := trailer[0] == pak && trailer[1] == nul
-	if methodNoGate && (eofARC || eofPAK) {
-		return true
+// Limit matches the LIMIT archive format.
+func Limit(r io.ReaderAt) bool {
+	const size = 4
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	return bytes.Equal(p, []byte{'L', 'I', 'M', 0x1a})
+}
+
+// Lbr matches the CP/M LBR library format created by the LU utility.
+// The first directory entry describes the directory itself, so it is active,
+// has a blank filename and starts at the first sector.
+func Lbr(r io.ReaderAt) bool {
+	const size = 16
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	const active = 0x00
+	blank := bytes.Repeat([]byte{' '}, 11)
+	return p[0] == active && bytes.Equal(p[1:12], blank) &&
+		binary.LittleEndian.Uint16(p[12:]) == 0 && binary.LittleEndian.Uint16(p[14:]) > 0
+}
+
+// Squeeze matches the CP/M Squeeze compression format, which
+// is followed by a checksum and the NUL terminated original filename.
+func Squeeze(r io.ReaderAt) bool {
+	return cpmMagic(r, 0xff, 4)
+}
+
+// Crunch matches the CP/M Crunch LZW compression format, which
+// is followed by the NUL terminated original filename.
+func Crunch(r io.ReaderAt) bool {
+	return cpmMagic(r, 0xfe, 2)
+}
+
+// CrunchLZH matches the CP/M CRLZH compression format, which
+// is followed by the NUL terminated original filename.
+func CrunchLZH(r io.ReaderAt) bool {
+	return cpmMagic(r, 0xfd, 2)
+}
+
+// cpmMagic returns true if the reader starts with the 0x76 byte and the id,
+// and a valid filename is stored at the offset.
+func cpmMagic(r io.ReaderAt, id byte, offset int) bool {
+	size := offset + cpmNameLen + 1
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, int64(size))
+	n, _ := sr.Read(p)
+	if n <= offset || p[0] != 0x76 || p[1] != id {
+		return false
 	}
-	if trailer[0] == pak && trailer[1] == nul {
+	_, ok := cpmName(p[offset:n])
+	return ok
+}
+
+// StuffIt matches the classic StuffIt archive format by Raymond Lau,
+// which has one of the StuffIt signatures followed by "rLau" at offset 10.
+func StuffIt(r io.ReaderAt) bool {
+	const size = 14
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	if !bytes.Equal(p[10:14], []byte("rLau")) {
+		return false
+	}
+	return slices.Contains([]string{
+		"SIT!", "ST46", "ST50", "ST60", "ST65", "STin", "STi2", "STi3", "STi4",
+	}, string(p[:4]))
+}
+
+// StuffIt5 matches the StuffIt 5 archive format, which starts with a copyright text.
+func StuffIt5(r io.ReaderAt) bool {
+	const size = 16
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	return bytes.Equal(p, []byte("StuffIt (c)1997-"))
+}
+
+// CompactPro matches the Compact Pro archive format by Bill Goodman.
+// The signature is a single byte, so the directory that is located by the header
+// and the Macintosh filename of the first entry are also checked.
+func CompactPro(r io.ReaderAt) bool {
+	const size = 8
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	const magic = 0x01
+	dir := int64(binary.BigEndian.Uint32(p[4:]))
+	if p[0] != magic || dir < size {
+		return false
+	}
+	// the directory is a checksum, the number of entries and the comment, then the entries
+	const dirLen, maxComment, entryLen = 7, 255, 1 + 31 + 5
+	d := make([]byte, dirLen+maxComment+entryLen)
+	sr = io.NewSectionReader(r, dir, int64(len(d)))
+	n, _ := sr.Read(d)
+	if n < dirLen || binary.BigEndian.Uint16(d[4:]) == 0 {
+		return false
+	}
+	entry := d[dirLen+int(d[6]) : n]
+	if len(entry) == 0 {
+		return false
+	}
+	const directory = 0x80
+	nameLen := int(entry[0] &^ directory)
+	if nameLen == 0 || nameLen > 31 || len(entry) < 1+nameLen {
+		return false
+	}
+	if !macName(entry[1 : 1+nameLen]) {
+		return false
+	}
+	if entry[0]&directory != 0 {
 		return true
 	}
+	// a file entry is followed by the volume number and the offset of the compressed data
+	const offsetLen = 5
+	if len(entry) < 1+nameLen+offsetLen {
+		return false
+	}
+	offset := int64(binary.BigEndian.Uint32(entry[1+nameLen+1:]))
+	return offset >= size && offset <= dir
+}
+
+// MacBin matches the MacBinary I, II and III encoded Macintosh file formats.
+// MacBinary I has no signature, so the header fields are checked for valid values,
+// while MacBinary II and III are also confirmed by the checksum of the header.
+func MacBin(r io.ReaderAt) bool {
+	p := make([]byte, macBinaryLen)
+	sr := io.NewSectionReader(r, 0, macBinaryLen)
+	if n, err := sr.Read(p); err != nil || n < macBinaryLen {
+		return false
+	}
+	return macBinary(p, Length(r)) > 0
+}
+
+// AppleSingleFile matches the AppleSingle encoded Macintosh file format.
+func AppleSingleFile(r io.ReaderAt) bool {
+	return appleMagic(r, appleSingle)
+}
+
+// AppleDoubleFile matches the AppleDouble encoded Macintosh file format,
+// which is the header file that contains the resource fork and the Finder information.
+func AppleDoubleFile(r io.ReaderAt) bool {
+	return appleMagic(r, appleDouble)
+}
+
+// appleMagic returns true if the reader starts with the magic and a version 1 or 2 number.
+func appleMagic(r io.ReaderAt, magic uint32) bool {
+	const size = 8
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	const v1, v2 = 0x00010000, 0x00020000
+	version := binary.BigEndian.Uint32(p[4:])
+	return binary.BigEndian.Uint32(p) == magic && (version == v1 || version == v2)
+}
 
+// BinHex4 matches the BinHex 4.0 encoded Macintosh file format.
+// The encoded data is text that follows the BinHex notice, which is often
+// placed after an email or a Usenet header.
+func BinHex4(r io.ReaderAt) bool {
+	const size = 4096
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	n, _ := sr.Read(p)
+	_, data, found := bytes.Cut(p[:n], []byte("(This file must be converted with BinHex 4.0)"))
+	if !found {
+		return false
+	}
+	// the encoded data starts with a colon on a new line
+	data = bytes.TrimLeft(data, "\r\n\t ")
+	return len(data) > 0 && data[0] == ':'
+}
+
+// Lzx matches the LZX compressed archive for the Amiga by Jonathan Forbes.
+// The signature is weak, so the CRC-32 of the first entry header, its filename and comment must be valid.
+func Lzx(r io.ReaderAt) bool {
+	const infoLen, entryLen = 10, 31
+	const size = infoLen + entryLen
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	if !bytes.Equal(p[:3], []byte("LZX")) {
+		return false
+	}
+	entry := p[infoLen:]
+	const commentLen, crc, nameLen = 14, 26, 30
+	extra := make([]byte, int(entry[nameLen])+int(entry[commentLen]))
+	if _, err := r.ReadAt(extra, size); err != nil {
+		return false
+	}
+	// the checksum is of the header with the checksum field set to zero
+	want := binary.LittleEndian.Uint32(entry[crc:])
+	copy(entry[crc:], []byte{0, 0, 0, 0})
+	sum := crc32.NewIEEE()
+	_, _ = sum.Write(entry)
+	_, _ = sum.Write(extra)
+	return sum.Sum32() == want
+}
+
+// Dms matches the DMS Disk Masher compressed Amiga floppy disk image.
+// The info header that follows the signature must match its CRC-16 checksum.
+func Dms(r io.ReaderAt) bool {
+	const size = 56
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	if !bytes.Equal(p[:4], []byte("DMS!")) {
+		return false
+	}
+	return amigaCRC(p[4:size-2]) == binary.BigEndian.Uint16(p[size-2:])
+}
+
+// PowerPack matches the PowerPacker compressed data for the Amiga by Nico François.
+// The signature is followed by the offset lengths of one of the five compression efficiencies.
+func PowerPack(r io.ReaderAt) bool {
+	const size = 8
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	if !bytes.Equal(p[:4], []byte("PP20")) {
+		return false
+	}
+	const fast, mediocre, good, veryGood, best = 0x09090909, 0x090a0a0a, 0x090a0b0b, 0x090a0c0c, 0x090a0c0d
+	switch binary.BigEndian.Uint32(p[4:]) {
+	case fast, mediocre, good, veryGood, best:
+		return true
+	}
 	return false
 }
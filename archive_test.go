@@ -36,6 +36,7 @@ func TestPak(t *testing.T) {
 	be.Err(t, err, nil)
 	defer r.Close()
 	be.True(t, magicnumber.Pak(r))
+	be.True(t, !magicnumber.ArcSEA(r))
 }
 
 func TestArchive(t *testing.T) {
@@ -57,7 +58,8 @@ func TestZipReduce(t *testing.T) {
 	r, err := os.Open(tdfile(zipReduceFile))
 	be.Err(t, err, nil)
 	defer r.Close()
-	be.True(t, magicnumber.PkShrink(r))
+	be.True(t, magicnumber.PkReduce(r))
+	be.True(t, !magicnumber.PkShrink(r))
 }
 
 func TestZipShrink(t *testing.T) {
@@ -75,7 +77,8 @@ func TestZipImplode(t *testing.T) {
 	r, err := os.Open(tdfile(zipImplodeFile))
 	be.Err(t, err, nil)
 	defer r.Close()
-	be.True(t, magicnumber.Pkzip(r))
+	be.True(t, magicnumber.PkImplode(r))
+	be.True(t, !magicnumber.Pkzip(r))
 }
 
 func TestZipStore(t *testing.T) {
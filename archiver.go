@@ -0,0 +1,66 @@
+package magicnumber
+
+// Package file archiver.go contains the functions that read the archiver version from the
+// headers of the early BBS-era archive formats that are not otherwise parsed.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrArchiver = errors.New("archive format has no archiver version")
+
+// Archiver is the archive format and the version of the archiver program that created it.
+type Archiver struct {
+	Signature  Signature // Signature is the archive format
+	Version    string    // Version is the archiver or the format version, or empty when it is not stored
+	MinVersion string    // MinVersion is the archiver version needed to extract, or empty when it is not stored
+}
+
+// ArchiverVersion reads the archiver version from the header of an ACE, HA, Hyper,
+// Squeeze It, UltraCompressor II, DWC or LIMIT archive.
+//
+// The HA version is the version of the archive format, as the archiver version is not stored.
+// The DWC and LIMIT formats do not store a version, so only the signature is returned.
+// Any other format returns the ErrArchiver error.
+func ArchiverVersion(r io.ReaderAt) (Archiver, error) {
+	if r == nil {
+		return Archiver{}, ErrNilReader
+	}
+	const size = 28
+	p := make([]byte, size)
+	_, _ = r.ReadAt(p, 0)
+	switch {
+	case Ace(r):
+		// the versions are multiplied by 10, such as 20 for v2.0
+		const made, extract = 15, 14
+		return Archiver{
+			Signature:  ACEArchive,
+			Version:    fmt.Sprintf("%d.%d", p[made]/10, p[made]%10),
+			MinVersion: fmt.Sprintf("%d.%d", p[extract]/10, p[extract]%10),
+		}, nil
+	case Uc2(r):
+		// the file header is followed by the archive header
+		// that stores the versions multiplied by 100, such as 200 for v2.0
+		le := binary.LittleEndian
+		made, extract := le.Uint16(p[24:]), le.Uint16(p[26:])
+		return Archiver{
+			Signature:  UltraCompressorII,
+			Version:    fmt.Sprintf("%d.%d", made/100, made%100),
+			MinVersion: fmt.Sprintf("%d.%d", extract/100, extract%100),
+		}, nil
+	case Sqz(r):
+		return Archiver{Signature: SqueezeItArchive, Version: string(p[5:6])}, nil
+	case Limit(r):
+		return Archiver{Signature: LimitArchive}, nil
+	case Hyp(r):
+		return Archiver{Signature: HyperArchive, Version: fmt.Sprintf("%d.%d", p[3]>>4, p[3]&0x0f)}, nil
+	case Ha(r):
+		return Archiver{Signature: HarriHirvolaHA, Version: fmt.Sprint(p[4] >> 4)}, nil
+	case Dwc(r):
+		return Archiver{Signature: DeanCooperDWC}, nil
+	}
+	return Archiver{}, ErrArchiver
+}
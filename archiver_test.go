@@ -0,0 +1,107 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+// bbsArchives returns the headers of the early BBS-era archives and the archiver versions they store.
+func bbsArchives() []struct {
+	name    string
+	b       []byte
+	sign    magicnumber.Signature
+	version string
+	min     string
+} {
+	le := binary.LittleEndian
+	ace := []byte{0x4a, 0x86, 31, 0, 0, 0x00, 0x00, '*', '*', 'A', 'C', 'E', '*', '*', 20, 26, 2, 0}
+	ace = append(ace, make([]byte, 16)...)
+	// HA with 1 file, a HSC compressed entry of format version 2
+	ha := []byte{'H', 'A', 1, 0, 0x22}
+	ha = append(ha, make([]byte, 16)...)
+	ha = append(ha, "\x00FILE_ID.DIZ\x00\x02\x20\x00"...)
+	hyp := []byte{0x1a, 'H', 'P', 0x25, 0x10, 0, 0, 0}
+	sqz := []byte("HLSQZ1\x00\x00")
+	uc2 := []byte{'U', 'C', '2', 0x1a}
+	uc2 = le.AppendUint32(uc2, 1000)
+	uc2 = le.AppendUint32(uc2, 1000+0x01b2c3d4)
+	uc2 = append(uc2, 1)
+	uc2 = append(uc2, make([]byte, 11)...)
+	uc2 = le.AppendUint16(uc2, 300)
+	uc2 = le.AppendUint16(uc2, 200)
+	uc2 = append(uc2, 0)
+	// DWC with a single 34 byte directory entry and the trailer
+	dwc := append([]byte("FILE_ID.DIZ"), make([]byte, 23)...)
+	dwc = le.AppendUint16(dwc, 27)
+	dwc = append(dwc, 34)
+	dwc = append(dwc, make([]byte, 13+4)...)
+	dwc = le.AppendUint32(dwc, 1)
+	dwc = append(dwc, "DWC"...)
+	limit := []byte{'L', 'I', 'M', 0x1a, 0, 1, 0, 0}
+	return []struct {
+		name    string
+		b       []byte
+		sign    magicnumber.Signature
+		version string
+		min     string
+	}{
+		{"ACE", ace, magicnumber.ACEArchive, "2.6", "2.0"},
+		{"HA", ha, magicnumber.HarriHirvolaHA, "2", ""},
+		{"HYP", hyp, magicnumber.HyperArchive, "2.5", ""},
+		{"SQZ", sqz, magicnumber.SqueezeItArchive, "1", ""},
+		{"UC2", uc2, magicnumber.UltraCompressorII, "3.0", "2.0"},
+		{"DWC", dwc, magicnumber.DeanCooperDWC, "", ""},
+		{"LIMIT", limit, magicnumber.LimitArchive, "", ""},
+	}
+}
+
+func TestArchiverVersion(t *testing.T) {
+	t.Parallel()
+	t.Log("TestArchiverVersion")
+	_, err := magicnumber.ArchiverVersion(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	for _, tt := range bbsArchives() {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := bytes.NewReader(tt.b)
+			be.Equal(t, tt.sign, magicnumber.Find(r))
+			sign, err := magicnumber.Archive(r)
+			be.Err(t, err, nil)
+			be.Equal(t, tt.sign, sign)
+			be.True(t, slices.Contains(magicnumber.ArchivesBBS(), tt.sign))
+			arc, err := magicnumber.ArchiverVersion(r)
+			be.Err(t, err, nil)
+			be.Equal(t, tt.sign, arc.Signature)
+			be.Equal(t, tt.version, arc.Version)
+			be.Equal(t, tt.min, arc.MinVersion)
+		})
+	}
+	_, err = magicnumber.ArchiverVersion(strings.NewReader("not an archive"))
+	be.Err(t, err, magicnumber.ErrArchiver)
+}
+
+func TestArchiverWeak(t *testing.T) {
+	t.Parallel()
+	t.Log("TestArchiverWeak")
+	// text that starts with the weak signatures is not an archive
+	for _, s := range []string{
+		"HAND SCANNED BY THE SYSOP OF THE BOARD",
+		"HA HA HA, greetings to all the crews",
+		"\x1aHPZZ",
+		"HLSQZ archive",
+		"UC2\x1a with a bad component length",
+	} {
+		r := strings.NewReader(s)
+		be.True(t, !magicnumber.Ha(r))
+		be.True(t, !magicnumber.Hyp(r))
+		be.True(t, !magicnumber.Sqz(r))
+		be.True(t, !magicnumber.Uc2(r))
+		be.True(t, !magicnumber.Dwc(r))
+	}
+}
@@ -0,0 +1,232 @@
+package magicnumber
+
+// Package file arj.go contains the functions that parse the headers of the ARJ archive format by Robert Jung.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrArjHeader = errors.New("arj header is invalid")
+
+// ArjArchive is the main header and the file headers of an ARJ archive.
+type ArjArchive struct {
+	Name        string    // Name is the original filename of the archive
+	Comment     string    // Comment is the archive comment, decoded from CP437
+	Host        string    // Host is the operating system of the archiver
+	Created     time.Time // Created is the date and time the archive was created
+	Modified    time.Time // Modified is the date and time the archive was last modified
+	Files       []ArjFile // Files are the file entries of the archive, in the stored order
+	Version     int       // Version is the version number of the archiver
+	MinVersion  int       // MinVersion is the minimum version number of the archiver needed to extract
+	MultiVolume bool      // MultiVolume is true if the archive is a part of a multi-volume set
+	Secured     bool      // Secured is true if the archive has an ARJ-SECURITY envelope
+	Garbled     bool      // Garbled is true if the archive is password protected
+}
+
+// ArjFile is a file entry of an ARJ archive.
+type ArjFile struct {
+	Name           string    // Name is the path of the file, decoded from CP437
+	Comment        string    // Comment is the file comment, decoded from CP437
+	Type           string    // Type is the kind of entry, such as binary, text or directory
+	Modified       time.Time // Modified is the date and time the file was last modified
+	Offset         int64     // Offset is the position of the compressed data
+	CompressedSize int64     // CompressedSize is the size of the compressed data
+	OriginalSize   int64     // OriginalSize is the size of the file when uncompressed
+	CRC32          uint32    // CRC32 is the checksum of the uncompressed file
+	Method         ArjMethod // Method is the compression method
+	Garbled        bool      // Garbled is true if the file is password protected
+	Continued      bool      // Continued is true if the file continues on the next volume
+}
+
+// ArjMethod is the compression method of an ARJ file entry.
+type ArjMethod int
+
+// String returns the name of the compression method.
+func (m ArjMethod) String() string {
+	switch m {
+	case 0:
+		return "stored"
+	case 1:
+		return "method 1, most compression"
+	case 2, 3:
+		return fmt.Sprintf("method %d", m)
+	case 4:
+		return "method 4, fastest"
+	case 8:
+		return "no data"
+	case 9:
+		return "no data, no crc"
+	}
+	return fmt.Sprintf("unknown method %d", int(m))
+}
+
+// ArjHeaders parses the main header and the file headers of the ARJ archive.
+// The filenames and comments are decoded from CP437 to UTF-8.
+func ArjHeaders(r io.ReaderAt) (ArjArchive, error) {
+	if r == nil {
+		return ArjArchive{}, ErrNilReader
+	}
+	const (
+		garbled   = 0x01
+		oldSecure = 0x02
+		volume    = 0x04
+		secured   = 0x40
+		firstLen  = 30
+	)
+	basic, offset, err := arjBasic(r, 0)
+	if err != nil {
+		return ArjArchive{}, err
+	}
+	const mainHeader = 2
+	if basic[6] != mainHeader {
+		return ArjArchive{}, fmt.Errorf("%w: not a main header", ErrArjHeader)
+	}
+	unix := arjUnixTime(basic[3])
+	name, comment := arjStrings(basic)
+	arc := ArjArchive{
+		Name:        DecodeCP437(name),
+		Comment:     DecodeCP437(comment),
+		Version:     int(basic[1]),
+		MinVersion:  int(basic[2]),
+		Host:        arjHost(basic[3]),
+		Created:     arjTime(binary.LittleEndian.Uint32(basic[8:]), unix),
+		Modified:    arjTime(binary.LittleEndian.Uint32(basic[12:]), unix),
+		Garbled:     basic[4]&garbled != 0,
+		MultiVolume: basic[4]&volume != 0,
+		Secured:     basic[4]&secured != 0 || basic[4]&oldSecure != 0 && arjEnvelope(basic),
+		Files:       []ArjFile{},
+	}
+	for range maxBlocks {
+		basic, next, err := arjBasic(r, offset)
+		if errors.Is(err, io.EOF) {
+			return arc, nil
+		}
+		if err != nil {
+			return arc, err
+		}
+		if basic[0] < firstLen {
+			return arc, fmt.Errorf("%w: file header at %d", ErrArjHeader, offset)
+		}
+		le := binary.LittleEndian
+		name, comment := arjStrings(basic)
+		file := ArjFile{
+			Name:           DecodeCP437(name),
+			Comment:        DecodeCP437(comment),
+			Type:           arjType(basic[6]),
+			Method:         ArjMethod(basic[5]),
+			Modified:       arjTime(le.Uint32(basic[8:]), arjUnixTime(basic[3])),
+			Offset:         next,
+			CompressedSize: int64(le.Uint32(basic[12:])),
+			OriginalSize:   int64(le.Uint32(basic[16:])),
+			CRC32:          le.Uint32(basic[20:]),
+			Garbled:        basic[4]&garbled != 0,
+			Continued:      basic[4]&volume != 0,
+		}
+		arc.Files = append(arc.Files, file)
+		offset = next + file.CompressedSize
+	}
+	return arc, nil
+}
+
+// arjBasic reads the basic header at the offset and skips the extended headers.
+// It returns the basic header and the offset of the data that follows the headers,
+// or io.EOF for the end of archive header.
+func arjBasic(r io.ReaderAt, offset int64) ([]byte, int64, error) {
+	const (
+		crcLen   = 4
+		extLen   = 2
+		minBasic = 16
+		maxBasic = 2600
+	)
+	p := make([]byte, 4)
+	if n, _ := r.ReadAt(p, offset); n < len(p) || p[0] != 0x60 || p[1] != 0xea {
+		return nil, 0, fmt.Errorf("%w: no header id at %d", ErrArjHeader, offset)
+	}
+	size := int64(binary.LittleEndian.Uint16(p[2:]))
+	if size == 0 {
+		return nil, 0, io.EOF
+	}
+	if size < minBasic || size > maxBasic {
+		return nil, 0, fmt.Errorf("%w: header size %d at %d", ErrArjHeader, size, offset)
+	}
+	basic := make([]byte, size)
+	if _, err := r.ReadAt(basic, offset+4); err != nil || int64(basic[0]) > size {
+		return nil, 0, fmt.Errorf("%w: header at %d", ErrArjHeader, offset)
+	}
+	offset += 4 + size + crcLen
+	for range maxBlocks {
+		ext := make([]byte, extLen)
+		if n, _ := r.ReadAt(ext, offset); n < extLen {
+			return nil, 0, fmt.Errorf("%w: extended header at %d", ErrArjHeader, offset)
+		}
+		offset += extLen
+		extSize := int64(binary.LittleEndian.Uint16(ext))
+		if extSize == 0 {
+			break
+		}
+		offset += extSize + crcLen
+	}
+	return basic, offset, nil
+}
+
+// arjStrings returns the NUL terminated filename and comment that follow the first header.
+func arjStrings(basic []byte) ([]byte, []byte) {
+	name, rest, _ := bytes.Cut(basic[basic[0]:], []byte{0})
+	comment, _, _ := bytes.Cut(rest, []byte{0})
+	return name, comment
+}
+
+// arjUnixTime returns true if the host operating system stores the timestamps as Unix time.
+func arjUnixTime(host byte) bool {
+	const unix, next = 2, 8
+	return host == unix || host == next
+}
+
+// arjTime returns the time of an ARJ timestamp,
+// which is either an MS-DOS date and time or a Unix time.
+func arjTime(stamp uint32, unix bool) time.Time {
+	if unix {
+		if stamp == 0 {
+			return time.Time{}
+		}
+		return time.Unix(int64(stamp), 0).UTC()
+	}
+	return DosTime(uint16(stamp>>16), uint16(stamp))
+}
+
+// arjHost returns the name of the host operating system.
+func arjHost(host byte) string {
+	hosts := [...]string{
+		"MS-DOS", "PRIMOS", "Unix", "Amiga", "Mac OS", "OS/2",
+		"Apple GS", "Atari ST", "NeXT", "VAX VMS", "Windows 95", "Win32",
+	}
+	if int(host) < len(hosts) {
+		return hosts[host]
+	}
+	return fmt.Sprintf("unknown host %d", host)
+}
+
+// arjEnvelope returns true if the main header has the position and the length of a security envelope.
+// ARJ32 reuses the old security flag as the ANSI code page flag, so the flag alone is not enough.
+func arjEnvelope(basic []byte) bool {
+	const position, length = 20, 26
+	if int(basic[0]) < length+2 || len(basic) < length+2 {
+		return false
+	}
+	le := binary.LittleEndian
+	return le.Uint32(basic[position:]) != 0 && le.Uint16(basic[length:]) != 0
+}
+
+// arjType returns the name of the file type.
+func arjType(fileType byte) string {
+	types := [...]string{"binary", "text", "comment", "directory", "volume label", "chapter label"}
+	if int(fileType) < len(types) {
+		return types[fileType]
+	}
+	return fmt.Sprintf("unknown type %d", fileType)
+}
@@ -0,0 +1,84 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestArjHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestArjHeaders")
+	_, err := magicnumber.ArjHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(arjFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	arc, err := magicnumber.ArjHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, "ARJ310.ARJ", arc.Name)
+	be.Equal(t, 11, arc.Version)
+	be.Equal(t, 1, arc.MinVersion)
+	be.Equal(t, "Unix", arc.Host)
+	be.Equal(t, time.Date(2024, time.January, 28, 6, 57, 4, 0, time.UTC), arc.Created)
+	be.True(t, !arc.MultiVolume)
+	be.True(t, !arc.Secured)
+	be.True(t, !arc.Garbled)
+	be.Equal(t, 15, len(arc.Files))
+
+	ans := arc.Files[0]
+	be.Equal(t, "TEST.ANS", ans.Name)
+	be.Equal(t, "stored", ans.Method.String())
+	be.Equal(t, "binary", ans.Type)
+	be.Equal(t, int64(68), ans.CompressedSize)
+	be.Equal(t, int64(68), ans.OriginalSize)
+	be.Equal(t, uint32(0x5ce2f707), ans.CRC32)
+	be.Equal(t, time.Date(2012, time.September, 19, 4, 21, 52, 0, time.UTC), ans.Modified)
+
+	bmp := arc.Files[2]
+	be.Equal(t, "TEST.BMP", bmp.Name)
+	be.Equal(t, magicnumber.ArjMethod(1), bmp.Method)
+	be.Equal(t, int64(2475), bmp.CompressedSize)
+	be.Equal(t, int64(750054), bmp.OriginalSize)
+	// the offset is the start of the compressed data
+	p := make([]byte, ans.CompressedSize)
+	_, err = r.ReadAt(p, ans.Offset)
+	be.Err(t, err, nil)
+	be.True(t, bytes.HasPrefix(p, []byte("\x1b[1mThis")))
+
+	// the 0x02 flag is the ANSI code page of ARJ32, and only an old security flag with an envelope
+	arj, err := os.ReadFile(tdfile(arjFile))
+	be.Err(t, err, nil)
+	main := func(fn func(basic []byte)) *bytes.Reader {
+		b := bytes.Clone(arj)
+		size := int(binary.LittleEndian.Uint16(b[2:]))
+		fn(b[4 : 4+size])
+		binary.LittleEndian.PutUint32(b[4+size:], crc32.ChecksumIEEE(b[4:4+size]))
+		return bytes.NewReader(b)
+	}
+	const win32, ansiPage, secured = 11, 0x02, 0x40
+	arc, err = magicnumber.ArjHeaders(main(func(basic []byte) { basic[3], basic[4] = win32, ansiPage }))
+	be.Err(t, err, nil)
+	be.Equal(t, "Win32", arc.Host)
+	be.True(t, !arc.Secured)
+	arc, err = magicnumber.ArjHeaders(main(func(basic []byte) { basic[3], basic[4] = win32, secured }))
+	be.Err(t, err, nil)
+	be.True(t, arc.Secured)
+	arc, err = magicnumber.ArjHeaders(main(func(basic []byte) {
+		basic[4] = ansiPage
+		binary.LittleEndian.PutUint32(basic[20:], 1000)
+		binary.LittleEndian.PutUint16(basic[26:], 44)
+	}))
+	be.Err(t, err, nil)
+	be.True(t, arc.Secured)
+
+	_, err = magicnumber.ArjHeaders(bytes.NewReader([]byte("not an arj archive")))
+	be.Err(t, err, magicnumber.ErrArjHeader)
+}
@@ -0,0 +1,97 @@
+package magicnumber
+
+// Package file bzip2.go contains the functions that parse the stream headers of the Bzip2 compression format.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrBzip2Header = errors.New("bzip2 header is invalid")
+
+// Bzip2Stream is a stream of a Bzip2 file, most files have a single stream,
+// but files created by parallel compressors have one stream for each part.
+type Bzip2Stream struct {
+	Offset    int64  // Offset is the position of the stream header
+	Level     int    // Level is the block size level, from 1 to 9
+	BlockSize int    // BlockSize is the maximum size of the uncompressed data of a block, the level times 100,000 bytes
+	Blocks    int    // Blocks is the number of compressed blocks in the stream
+	CRC32     uint32 // CRC32 is the combined checksum of the blocks that is stored in the end of stream marker
+}
+
+const (
+	bzip2Block = 0x314159265359 // bzip2Block is the magic of a compressed block, the BCD digits of pi
+	bzip2End   = 0x177245385090 // bzip2End is the magic of the end of stream marker, the BCD digits of sqrt(pi)
+)
+
+// Bzip2Headers reads the stream headers of a Bzip2 file and returns the streams in the stored order.
+// The compressed blocks are not aligned to bytes, so the data is scanned bit by bit for the block
+// and the end of stream magic values. Any data after the last stream that is not a stream header
+// is ignored.
+func Bzip2Headers(r io.ReaderAt) ([]Bzip2Stream, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	const (
+		headerLen = 4
+		magicBits = 48
+		magicMask = 1<<magicBits - 1
+		crcBits   = 32
+	)
+	streams := []Bzip2Stream{}
+	offset := int64(0)
+	for range maxBlocks {
+		p := make([]byte, headerLen)
+		if n, _ := r.ReadAt(p, offset); n < headerLen || string(p[:3]) != "BZh" || p[3] < '1' || p[3] > '9' {
+			if len(streams) > 0 {
+				return streams, nil
+			}
+			return nil, fmt.Errorf("%w: no stream at %d", ErrBzip2Header, offset)
+		}
+		const blockSize = 100000
+		stream := Bzip2Stream{
+			Offset:    offset,
+			Level:     int(p[3] - '0'),
+			BlockSize: int(p[3]-'0') * blockSize,
+		}
+		br := bufio.NewReader(io.NewSectionReader(r, offset+headerLen, 1<<62))
+		var cur byte
+		left, read := 0, int64(0)
+		bit := func() (uint64, error) {
+			if left == 0 {
+				b, err := br.ReadByte()
+				if err != nil {
+					return 0, err
+				}
+				cur, left = b, 8
+				read++
+			}
+			left--
+			return uint64(cur >> left & 1), nil
+		}
+		var reg uint64
+		for reg&magicMask != bzip2End {
+			b, err := bit()
+			if err != nil {
+				return streams, fmt.Errorf("%w: no end of stream marker after %d", ErrBzip2Header, offset)
+			}
+			reg = reg<<1 | b
+			if reg&magicMask == bzip2Block {
+				stream.Blocks++
+			}
+		}
+		for range crcBits {
+			b, err := bit()
+			if err != nil {
+				return streams, fmt.Errorf("%w: no stream checksum after %d", ErrBzip2Header, offset)
+			}
+			stream.CRC32 = stream.CRC32<<1 | uint32(b)
+		}
+		// the end of stream is padded to a byte boundary
+		offset += headerLen + read
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
@@ -0,0 +1,51 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestBzip2Headers(t *testing.T) {
+	t.Parallel()
+	t.Log("TestBzip2Headers")
+	_, err := magicnumber.Bzip2Headers(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	b, err := os.ReadFile(tdfile(b2zFile))
+	be.Err(t, err, nil)
+	streams, err := magicnumber.Bzip2Headers(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.Equal(t, 1, len(streams))
+	s := streams[0]
+	be.Equal(t, 9, s.Level)
+	be.Equal(t, 900000, s.BlockSize)
+	be.Equal(t, 1, s.Blocks)
+	// the combined checksum of a single block stream is the block checksum
+	be.Equal(t, uint32(0xf3c3d4ca), s.CRC32)
+
+	// parallel compressors concatenate the streams
+	two := append(append([]byte{}, b...), b...)
+	streams, err = magicnumber.Bzip2Headers(bytes.NewReader(two))
+	be.Err(t, err, nil)
+	be.Equal(t, 2, len(streams))
+	be.Equal(t, int64(len(b)), streams[1].Offset)
+
+	// an empty stream has no blocks
+	empty := []byte{'B', 'Z', 'h', '1', 0x17, 0x72, 0x45, 0x38, 0x50, 0x90, 0, 0, 0, 0}
+	be.True(t, magicnumber.Bzip2(bytes.NewReader(empty)))
+	streams, err = magicnumber.Bzip2Headers(bytes.NewReader(empty))
+	be.Err(t, err, nil)
+	be.Equal(t, 1, streams[0].Level)
+	be.Equal(t, 0, streams[0].Blocks)
+
+	_, err = magicnumber.Bzip2Headers(bytes.NewReader(b[:len(b)/2]))
+	be.Err(t, err, magicnumber.ErrBzip2Header)
+	_, err = magicnumber.Bzip2Headers(strings.NewReader("BZh0 is not a level"))
+	be.Err(t, err, magicnumber.ErrBzip2Header)
+	be.True(t, !magicnumber.Bzip2(strings.NewReader("BZh0 is not a level")))
+}
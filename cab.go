@@ -0,0 +1,201 @@
+package magicnumber
+
+// Package file cab.go contains the functions that parse the headers of the Microsoft Cabinet archive format.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrCabHeader = errors.New("cab header is invalid")
+
+// CabCabinet is the cabinet header, the folders and the files of a Microsoft Cabinet archive.
+type CabCabinet struct {
+	Version     string      // Version is the version of the cabinet format, such as 1.3
+	PrevCabinet string      // PrevCabinet is the filename of the previous cabinet of a spanned set
+	PrevDisk    string      // PrevDisk is the name of the disk that contains the previous cabinet
+	NextCabinet string      // NextCabinet is the filename of the next cabinet of a spanned set
+	NextDisk    string      // NextDisk is the name of the disk that contains the next cabinet
+	Folders     []CabFolder // Folders are the compressed blocks of the cabinet
+	Files       []CabFile   // Files are the file entries of the cabinet, in the stored order
+	Size        int64       // Size is the size of the cabinet file in bytes
+	SetID       uint16      // SetID is the identifier that is shared by the cabinets of a spanned set
+	Index       int         // Index is the zero-based number of the cabinet in a spanned set
+}
+
+// CabFolder is a folder of a cabinet, which is a block of compressed data that contains one or more files.
+type CabFolder struct {
+	Offset      int64          // Offset is the position of the first data block of the folder
+	Blocks      int            // Blocks is the number of the data blocks in the folder
+	Compression CabCompression // Compression is the compression type of the folder
+	Level       int            // Level is the Quantum compression level or the LZX window size in bits
+}
+
+// CabFile is a file entry of a cabinet.
+type CabFile struct {
+	Name         string        // Name is the path of the file, decoded from UTF-8 or CP437
+	Modified     time.Time     // Modified is the MS-DOS date and time the file was last modified
+	Size         int64         // Size is the size of the file when uncompressed
+	FolderOffset int64         // FolderOffset is the position of the file in the uncompressed folder
+	Folder       int           // Folder is the index of the folder that contains the file, or -1 for a file that spans cabinets
+	Attributes   CabAttributes // Attributes are the file attributes
+}
+
+// CabCompression is the compression type of a cabinet folder.
+type CabCompression int
+
+// String returns the name of the compression type.
+func (c CabCompression) String() string {
+	switch c {
+	case 0:
+		return "none"
+	case 1:
+		return "MSZIP"
+	case 2:
+		return "Quantum"
+	case 3:
+		return "LZX"
+	}
+	return fmt.Sprintf("unknown compression %d", int(c))
+}
+
+// CabAttributes are the attributes of a cabinet file.
+type CabAttributes uint16
+
+const (
+	CabReadOnly CabAttributes = 0x01 // CabReadOnly is a read-only file
+	CabHidden   CabAttributes = 0x02 // CabHidden is a hidden file
+	CabSystem   CabAttributes = 0x04 // CabSystem is a system file
+	CabArchive  CabAttributes = 0x20 // CabArchive is a file that was modified since the last backup
+	CabExecute  CabAttributes = 0x40 // CabExecute is a file to run after extraction
+	CabUTF8     CabAttributes = 0x80 // CabUTF8 is a file with a UTF-8 encoded name
+)
+
+// String returns the letters of the attributes that are set, such as "RA" for a read-only archive file.
+func (a CabAttributes) String() string {
+	var s strings.Builder
+	for _, attr := range []struct {
+		flag   CabAttributes
+		letter byte
+	}{
+		{CabReadOnly, 'R'}, {CabHidden, 'H'}, {CabSystem, 'S'}, {CabArchive, 'A'}, {CabExecute, 'X'},
+	} {
+		if a&attr.flag != 0 {
+			s.WriteByte(attr.letter)
+		}
+	}
+	return s.String()
+}
+
+// CabHeaders parses the CFHEADER, the CFFOLDER and the CFFILE structures of a Microsoft Cabinet archive.
+func CabHeaders(r io.ReaderAt) (CabCabinet, error) {
+	if r == nil {
+		return CabCabinet{}, ErrNilReader
+	}
+	const (
+		headerLen   = 36
+		reserveLen  = 4
+		folderLen   = 8
+		prevCabinet = 0x0001
+		nextCabinet = 0x0002
+		reserve     = 0x0004
+		maxName     = 256
+	)
+	p := make([]byte, headerLen+reserveLen)
+	if n, _ := r.ReadAt(p, 0); n < headerLen || !Cab(r) {
+		return CabCabinet{}, fmt.Errorf("%w: no signature", ErrCabHeader)
+	}
+	le := binary.LittleEndian
+	cab := CabCabinet{
+		Version: fmt.Sprintf("%d.%d", p[25], p[24]),
+		Size:    int64(le.Uint32(p[8:])),
+		SetID:   le.Uint16(p[32:]),
+		Index:   int(le.Uint16(p[34:])),
+		Folders: []CabFolder{},
+		Files:   []CabFile{},
+	}
+	filesOffset := int64(le.Uint32(p[16:]))
+	folders, files := int(le.Uint16(p[26:])), int(le.Uint16(p[28:]))
+	flags := le.Uint16(p[30:])
+	offset := int64(headerLen)
+	folderReserve := int64(0)
+	if flags&reserve != 0 {
+		offset += reserveLen + int64(le.Uint16(p[headerLen:]))
+		folderReserve = int64(p[headerLen+2])
+	}
+	next := func() string {
+		b := make([]byte, maxName)
+		n, _ := r.ReadAt(b, offset)
+		s, _, _ := bytes.Cut(b[:n], []byte{0})
+		offset += int64(len(s)) + 1
+		return DecodeCP437(s)
+	}
+	if flags&prevCabinet != 0 {
+		cab.PrevCabinet, cab.PrevDisk = next(), next()
+	}
+	if flags&nextCabinet != 0 {
+		cab.NextCabinet, cab.NextDisk = next(), next()
+	}
+	for range folders {
+		f := make([]byte, folderLen)
+		if n, _ := r.ReadAt(f, offset); n < folderLen {
+			return cab, fmt.Errorf("%w: folder at %d", ErrCabHeader, offset)
+		}
+		compress := le.Uint16(f[6:])
+		cab.Folders = append(cab.Folders, CabFolder{
+			Offset:      int64(le.Uint32(f)),
+			Blocks:      int(le.Uint16(f[4:])),
+			Compression: CabCompression(compress & 0x000f),
+			Level:       int(compress >> 8 & 0x1f),
+		})
+		offset += folderLen + folderReserve
+	}
+	offset = filesOffset
+	for range files {
+		file, size, err := cabFile(r, offset)
+		if err != nil {
+			return cab, err
+		}
+		cab.Files = append(cab.Files, file)
+		offset += size
+	}
+	return cab, nil
+}
+
+// cabFile reads the CFFILE structure at the offset and returns the file and the size of the structure.
+func cabFile(r io.ReaderAt, offset int64) (CabFile, int64, error) {
+	const fileLen, maxName = 16, 256
+	p := make([]byte, fileLen+maxName)
+	n, _ := r.ReadAt(p, offset)
+	if n <= fileLen {
+		return CabFile{}, 0, fmt.Errorf("%w: file at %d", ErrCabHeader, offset)
+	}
+	name, _, found := bytes.Cut(p[fileLen:n], []byte{0})
+	if !found {
+		return CabFile{}, 0, fmt.Errorf("%w: filename at %d", ErrCabHeader, offset)
+	}
+	le := binary.LittleEndian
+	file := CabFile{
+		Size:         int64(le.Uint32(p)),
+		FolderOffset: int64(le.Uint32(p[4:])),
+		Folder:       int(le.Uint16(p[8:])),
+		Modified:     DosTime(le.Uint16(p[10:]), le.Uint16(p[12:])),
+		Attributes:   CabAttributes(le.Uint16(p[14:])),
+	}
+	// the folder indexes 0xfffd to 0xffff are files that continue from or to another cabinet
+	const continued = 0xfffd
+	if file.Folder >= continued {
+		file.Folder = -1
+	}
+	file.Name = DecodeCP437(name)
+	if file.Attributes&CabUTF8 != 0 {
+		file.Name = string(name)
+	}
+	file.Name = strings.ReplaceAll(file.Name, "\\", "/")
+	return file, fileLen + int64(len(name)) + 1, nil
+}
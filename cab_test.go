@@ -0,0 +1,89 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestCabHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCabHeaders")
+	_, err := magicnumber.CabHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	_, err = magicnumber.CabHeaders(bytes.NewReader([]byte("MSCF is not a cabinet")))
+	be.Err(t, err, magicnumber.ErrCabHeader)
+
+	r, err := os.Open(tdfile(cabFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	cab, err := magicnumber.CabHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, "1.3", cab.Version)
+	be.Equal(t, int64(253), cab.Size)
+	be.Equal(t, uint16(0x0622), cab.SetID)
+	be.Equal(t, 0, cab.Index)
+	be.Equal(t, "", cab.PrevCabinet+cab.NextCabinet)
+	be.Equal(t, 1, len(cab.Folders))
+	be.Equal(t, "none", cab.Folders[0].Compression.String())
+	be.Equal(t, int64(0x5e), cab.Folders[0].Offset)
+	be.Equal(t, 2, len(cab.Files))
+	hello := cab.Files[0]
+	be.Equal(t, "hello.c", hello.Name)
+	be.Equal(t, int64(77), hello.Size)
+	be.Equal(t, int64(0), hello.FolderOffset)
+	be.Equal(t, 0, hello.Folder)
+	be.Equal(t, "A", hello.Attributes.String())
+	be.Equal(t, time.Date(1997, time.March, 12, 11, 13, 52, 0, time.UTC), hello.Modified)
+	be.Equal(t, "welcome.c", cab.Files[1].Name)
+	be.Equal(t, int64(77), cab.Files[1].FolderOffset)
+}
+
+func TestCabSpanned(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCabSpanned")
+	le := binary.LittleEndian
+	cab := make([]byte, 36)
+	copy(cab, "MSCF")
+	cab[24], cab[25] = 3, 1
+	le.PutUint16(cab[26:], 1)
+	le.PutUint16(cab[28:], 1)
+	le.PutUint16(cab[30:], 0x0001|0x0002|0x0004)
+	le.PutUint16(cab[32:], 1234)
+	le.PutUint16(cab[34:], 1)
+	// the reserved sizes of the header, the folders and the data blocks
+	cab = append(cab, 2, 0, 1, 0, 0xaa, 0xbb)
+	cab = append(cab, "DISK1.CAB\x00Disk 1\x00DISK3.CAB\x00Disk 3\x00"...)
+	// an LZX folder with a 2 MB window followed by a reserved byte
+	cab = append(cab, 0, 0, 0, 0, 1, 0, 0x03, 0x15, 0xcc)
+	le.PutUint32(cab[16:], uint32(len(cab)))
+	file := make([]byte, 16)
+	le.PutUint32(file, 1000)
+	le.PutUint16(file[8:], 0xfffd)
+	le.PutUint16(file[10:], 0x4133)
+	le.PutUint16(file[14:], uint16(magicnumber.CabReadOnly|magicnumber.CabArchive|magicnumber.CabUTF8))
+	cab = append(cab, file...)
+	cab = append(cab, "docs\\ŧëxŧ.txt\x00"...)
+	le.PutUint32(cab[8:], uint32(len(cab)))
+
+	c, err := magicnumber.CabHeaders(bytes.NewReader(cab))
+	be.Err(t, err, nil)
+	be.Equal(t, uint16(1234), c.SetID)
+	be.Equal(t, 1, c.Index)
+	be.Equal(t, "DISK1.CAB", c.PrevCabinet)
+	be.Equal(t, "Disk 1", c.PrevDisk)
+	be.Equal(t, "DISK3.CAB", c.NextCabinet)
+	be.Equal(t, "Disk 3", c.NextDisk)
+	be.Equal(t, []magicnumber.CabFolder{{Blocks: 1, Compression: 3, Level: 21}}, c.Folders)
+	be.Equal(t, "LZX", c.Folders[0].Compression.String())
+	be.Equal(t, 1, len(c.Files))
+	be.Equal(t, "docs/ŧëxŧ.txt", c.Files[0].Name)
+	be.Equal(t, -1, c.Files[0].Folder)
+	be.Equal(t, "RA", c.Files[0].Attributes.String())
+	be.Equal(t, int64(1000), c.Files[0].Size)
+}
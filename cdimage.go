@@ -62,3 +62,23 @@ func Nri(r io.ReaderAt) bool {
 	}
 	return bytes.Equal(p, []byte{0x0e, 'N', 'e', 'r', 'o', 'I', 'S', 'O'})
 }
+
+// Adf returns true if the reader contains an Amiga Disk File floppy image.
+// A DOS disk requires the root block of the original or fast file system,
+// while a non-DOS disk, such as a demo that uses its own track loader,
+// requires the size of a floppy disk and a bootable boot block checksum.
+func Adf(r io.ReaderAt) bool {
+	const size = adfBootLen
+	p := make([]byte, size)
+	sr := io.NewSectionReader(r, 0, size)
+	if n, err := sr.Read(p); err != nil || n < size {
+		return false
+	}
+	if adfDOS(p) {
+		if _, found := adfRoot(r); found {
+			return true
+		}
+	}
+	length := Length(r)
+	return (length == adfDD || length == adfHD) && adfBootable(p)
+}
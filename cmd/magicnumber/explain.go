@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Defacto2/magicnumber"
+)
+
+var errNoFile = errors.New("missing path to file")
+
+// explain prints every matcher tried in precedence order, whether it matched,
+// the byte ranges used as evidence and the text heuristic statistics.
+func explain(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	matched := fs.Bool("matched", false, "only list the matchers that matched")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: magicnumber explain [-matched] <path-to-file>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return errNoFile
+	}
+	name := fs.Arg(0)
+	file, err := os.Open(name) //nolint:gosec
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer file.Close()
+
+	x, err := magicnumber.Explain(file)
+	if err != nil {
+		return fmt.Errorf("explain %s: %w", name, err)
+	}
+	fmt.Fprintf(w, "%s : %s %q\n\n", name, x.Result, x.Result.Title())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tmatcher\tsignature\tmatch\tevidence")
+	found := false
+	for i, step := range x.Steps {
+		if *matched && !step.Matched {
+			continue
+		}
+		match := "no"
+		if step.Matched {
+			match = "yes"
+		}
+		if step.Matched && step.Signature == x.Result && !found {
+			match = "yes, result"
+			found = true
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, step.Name, step.Signature, match, evidence(step.Evidence))
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("explain flush: %w", err)
+	}
+
+	t := x.Text
+	fmt.Fprintln(w, "\ntext heuristics")
+	fmt.Fprintf(w, "  size:                %d bytes\n", t.Size)
+	fmt.Fprintf(w, "  TxtW non-plain:      %d bytes, ratio %.4f (rejects >= 0.0200)\n", t.NonPlainText, t.Ratio)
+	fmt.Fprintf(w, "  CodePageW newlines:  %d CRLF\n", t.Newlines)
+	if t.EscapeOffset < 0 {
+		fmt.Fprintln(w, "  AnsiW escape:        none found")
+		return nil
+	}
+	fmt.Fprintf(w, "  AnsiW escape:        %s at offset %d\n", t.Escape, t.EscapeOffset)
+	return nil
+}
+
+// evidence returns the byte ranges as a list of inclusive offsets.
+func evidence(ranges []magicnumber.Evidence) string {
+	if len(ranges) == 0 {
+		return "-"
+	}
+	s := make([]string, 0, len(ranges))
+	for _, e := range ranges {
+		if e.Length == 1 {
+			s = append(s, fmt.Sprint(e.Offset))
+			continue
+		}
+		s = append(s, fmt.Sprintf("%d-%d", e.Offset, e.Offset+e.Length-1))
+	}
+	return strings.Join(s, ", ")
+}
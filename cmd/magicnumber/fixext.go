@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Defacto2/magicnumber"
+)
+
+var (
+	errNoDir     = errors.New("missing path to directory")
+	errLogExists = errors.New("undo log already exists, remove it or use -log to choose another file")
+	errUndoLine  = errors.New("malformed undo log line")
+)
+
+const logHeader = "# magicnumber fix-extensions undo log, renamed path <tab> original path"
+
+// rename is a planned change of a file extension.
+type rename struct {
+	from      string                // from is the original path
+	to        string                // to is the path using the canonical extension
+	sign      magicnumber.Signature // sign is the file type found in the file content
+	collision bool                  // collision is true when the target was numbered to avoid an existing file
+}
+
+// fixExtensions walks a directory tree and renames the files with a wrong or missing extension
+// to the canonical extension of the file type signature. Without the -apply flag it is a dry-run
+// that only prints the renames.
+func fixExtensions(w io.Writer, args []string) error {
+	fset := flag.NewFlagSet("fix-extensions", flag.ContinueOnError)
+	apply := fset.Bool("apply", false, "rename the files, otherwise only print the renames")
+	logName := fset.String("log", "magicnumber-undo.tsv", "undo log of the renames, written with -apply")
+	undoName := fset.String("undo", "", "reverse the renames recorded in an undo log")
+	skipMissing := fset.Bool("skip-missing", false, "ignore the files without an extension")
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: magicnumber fix-extensions [-apply] [-log file] [-skip-missing] <directory>")
+		fmt.Fprintln(fset.Output(), "       magicnumber fix-extensions -undo <file>")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if *undoName != "" {
+		return undo(w, *undoName)
+	}
+	if fset.NArg() < 1 {
+		fset.Usage()
+		return errNoDir
+	}
+	root := fset.Arg(0)
+	renames, err := plan(root, *skipMissing)
+	if err != nil {
+		return err
+	}
+	if !*apply {
+		for _, r := range renames {
+			fmt.Fprintf(w, "dry-run  %s\n", r)
+		}
+		fmt.Fprintf(w, "%d files to rename, use -apply to rename them\n", len(renames))
+		return nil
+	}
+	return applyRenames(w, *logName, renames)
+}
+
+func (r rename) String() string {
+	s := fmt.Sprintf("%s -> %s  %s", r.from, r.to, r.sign)
+	if r.collision {
+		s += " (numbered to avoid a collision)"
+	}
+	return s
+}
+
+// plan walks the root directory and returns the renames of the files whose
+// extension does not match the file type signature.
+func plan(root string, skipMissing bool) ([]rename, error) {
+	renames := []rename{}
+	targets := map[string]bool{}
+	exts := *magicnumber.Ext()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext == "" && skipMissing {
+			return nil
+		}
+		valid, sign, err := matchExt(path)
+		if err != nil || valid {
+			return nil //nolint:nilerr
+		}
+		canonical := exts[sign]
+		if len(canonical) == 0 {
+			return nil
+		}
+		to, collision := target(path, canonical[0], targets)
+		targets[to] = true
+		renames = append(renames, rename{from: path, to: to, sign: sign, collision: collision})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fix extensions walk %s: %w", root, err)
+	}
+	return renames, nil
+}
+
+func matchExt(path string) (bool, magicnumber.Signature, error) {
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return false, magicnumber.Unknown, err //nolint:wrapcheck
+	}
+	defer file.Close()
+	return magicnumber.MatchExt(filepath.Base(path), file) //nolint:wrapcheck
+}
+
+// target returns the path using the new extension. The case of the extension follows
+// the case of the original extension, or the filename when there is no extension, so
+// TEST.EXE becomes TEST.EX_ and readme becomes readme.txt.
+// When the path is taken, a number is appended to the filename.
+func target(path, ext string, taken map[string]bool) (string, bool) {
+	old := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, old)
+	sample := old
+	if sample == "" {
+		sample = filepath.Base(stem)
+	}
+	if sample == strings.ToUpper(sample) {
+		ext = strings.ToUpper(ext)
+	}
+	to := stem + ext
+	if !exists(to, taken) {
+		return to, false
+	}
+	for i := 1; ; i++ {
+		to = fmt.Sprintf("%s_%d%s", stem, i, ext)
+		if !exists(to, taken) {
+			return to, true
+		}
+	}
+}
+
+func exists(path string, taken map[string]bool) bool {
+	if taken[path] {
+		return true
+	}
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// applyRenames renames the files and records each rename in a new undo log.
+// The undo log is only created when there are files to rename and
+// it is removed again when none of the files could be renamed.
+func applyRenames(w io.Writer, logName string, renames []rename) error {
+	if exists(logName, nil) {
+		return fmt.Errorf("%w: %s", errLogExists, logName)
+	}
+	if len(renames) == 0 {
+		fmt.Fprintln(w, "0 files to rename")
+		return nil
+	}
+	log, err := os.OpenFile(logName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", errLogExists, logName)
+	}
+	if err != nil {
+		return fmt.Errorf("fix extensions undo log: %w", err)
+	}
+	defer log.Close()
+	fmt.Fprintln(log, logHeader)
+	renamed := 0
+	defer func() {
+		if renamed == 0 {
+			_ = log.Close()
+			_ = os.Remove(logName)
+		}
+	}()
+	for _, r := range renames {
+		from, err := filepath.Abs(r.from)
+		if err != nil {
+			return fmt.Errorf("fix extensions path: %w", err)
+		}
+		to, err := filepath.Abs(r.to)
+		if err != nil {
+			return fmt.Errorf("fix extensions path: %w", err)
+		}
+		if exists(to, nil) {
+			fmt.Fprintf(w, "skip     %s, the target was created after the walk\n", r)
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			fmt.Fprintf(w, "error    %s: %s\n", r, err)
+			continue
+		}
+		if _, err := fmt.Fprintf(log, "%s\t%s\n", to, from); err != nil {
+			return fmt.Errorf("fix extensions undo log: %w", err)
+		}
+		fmt.Fprintf(w, "renamed  %s\n", r)
+		renamed++
+	}
+	if renamed == 0 {
+		fmt.Fprintf(w, "0 of %d files renamed\n", len(renames))
+		return nil
+	}
+	fmt.Fprintf(w, "%d of %d files renamed, undo log: %s\n", renamed, len(renames), logName)
+	return nil
+}
+
+// undo reverses the renames recorded in the undo log, in the reverse order they were made.
+func undo(w io.Writer, logName string) error {
+	log, err := os.Open(logName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("fix extensions undo: %w", err)
+	}
+	defer log.Close()
+	type entry struct{ to, from string }
+	entries := []entry{}
+	scanner := bufio.NewScanner(log)
+	for line := 1; scanner.Scan(); line++ {
+		s := scanner.Text()
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		to, from, found := strings.Cut(s, "\t")
+		if !found {
+			return fmt.Errorf("%w %d: %s", errUndoLine, line, logName)
+		}
+		entries = append(entries, entry{to: to, from: from})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("fix extensions undo: %w", err)
+	}
+	restored := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if exists(e.from, nil) {
+			fmt.Fprintf(w, "skip     %s, the original path is in use\n", e.from)
+			continue
+		}
+		if err := os.Rename(e.to, e.from); err != nil {
+			fmt.Fprintf(w, "error    %s: %s\n", e.to, err)
+			continue
+		}
+		fmt.Fprintf(w, "restored %s -> %s\n", e.to, e.from)
+		restored++
+	}
+	fmt.Fprintf(w, "%d of %d files restored\n", restored, len(entries))
+	return nil
+}
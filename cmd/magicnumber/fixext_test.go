@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nalgeon/be"
+)
+
+// fixture returns the content of a file in the uncompressed testdata directory.
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("..", "..", "testdata", "uncompress", name))
+	be.Err(t, err, nil)
+	return b
+}
+
+// tree writes the files to a temporary directory and returns the directory.
+func tree(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, b := range files {
+		be.Err(t, os.WriteFile(filepath.Join(dir, name), b, 0o600), nil)
+	}
+	return dir
+}
+
+// names returns the sorted filenames in the directory.
+func names(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	be.Err(t, err, nil)
+	s := make([]string, 0, len(entries))
+	for _, e := range entries {
+		s = append(s, e.Name())
+	}
+	return s
+}
+
+func TestTarget(t *testing.T) {
+	t.Parallel()
+	t.Log("TestTarget")
+	dir := tree(t, map[string][]byte{"taken.png": nil})
+	tests := []struct {
+		name      string
+		ext       string
+		taken     map[string]bool
+		want      string
+		collision bool
+	}{
+		{"TEST.EXE", ".ex_", nil, "TEST.EX_", false},
+		{"test.exe", ".ex_", nil, "test.ex_", false},
+		{"Test.Exe", ".ex_", nil, "Test.ex_", false},
+		{"readme", ".txt", nil, "readme.txt", false},
+		{"README", ".txt", nil, "README.TXT", false},
+		{"taken.txt", ".png", nil, "taken_1.png", true},
+		{"logo.txt", ".png", map[string]bool{"logo.png": true, "logo_1.png": true}, "logo_2.png", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			taken := map[string]bool{}
+			for name := range tt.taken {
+				taken[filepath.Join(dir, name)] = true
+			}
+			to, collision := target(filepath.Join(dir, tt.name), tt.ext, taken)
+			be.Equal(t, filepath.Join(dir, tt.want), to)
+			be.Equal(t, tt.collision, collision)
+		})
+	}
+}
+
+func TestFixExtensions(t *testing.T) {
+	t.Parallel()
+	t.Log("TestFixExtensions")
+	png := fixture(t, "TEST.PNG")
+	files := map[string][]byte{
+		"logo.txt":    png,
+		"logo.png":    png,
+		"PHOTO.DAT":   png,
+		"noext":       png,
+		"README.NFO":  fixture(t, "TEST.NFO"),
+		"FILE_ID.DIZ": fixture(t, "TEST.DIZ"),
+		"NOTES.ASC":   fixture(t, "TEST.ASC"),
+		"READ.ME":     fixture(t, "TEST.ME"),
+	}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+		log  bool
+	}{
+		{
+			name: "dry-run",
+			want: []string{"FILE_ID.DIZ", "NOTES.ASC", "PHOTO.DAT", "READ.ME", "README.NFO", "logo.png", "logo.txt", "noext"},
+		},
+		{
+			name: "apply",
+			args: []string{"-apply"},
+			want: []string{"FILE_ID.DIZ", "NOTES.ASC", "PHOTO.PNG", "READ.ME", "README.NFO", "logo.png", "logo_1.png", "noext.png"},
+			log:  true,
+		},
+		{
+			name: "skip missing",
+			args: []string{"-apply", "-skip-missing"},
+			want: []string{"FILE_ID.DIZ", "NOTES.ASC", "PHOTO.PNG", "READ.ME", "README.NFO", "logo.png", "logo_1.png", "noext"},
+			log:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := tree(t, files)
+			logName := filepath.Join(t.TempDir(), "undo.tsv")
+			args := append([]string{"-log", logName}, tt.args...)
+			var w bytes.Buffer
+			be.Err(t, fixExtensions(&w, append(args, dir)), nil)
+			be.Equal(t, tt.want, names(t, dir))
+			_, err := os.Stat(logName)
+			be.Equal(t, tt.log, err == nil)
+			if !tt.log {
+				return
+			}
+			// an existing undo log is never overwritten and the undo restores the original names
+			be.Err(t, fixExtensions(&w, append(args, dir)), errLogExists)
+			be.Err(t, fixExtensions(&w, []string{"-undo", logName}), nil)
+			be.Equal(t, []string{"FILE_ID.DIZ", "NOTES.ASC", "PHOTO.DAT", "READ.ME", "README.NFO", "logo.png", "logo.txt", "noext"},
+				names(t, dir))
+		})
+	}
+}
+
+func TestFixExtensionsNone(t *testing.T) {
+	t.Parallel()
+	t.Log("TestFixExtensionsNone")
+	// a directory without any files to rename does not create the undo log
+	dir := tree(t, map[string][]byte{"logo.png": fixture(t, "TEST.PNG"), "README.NFO": fixture(t, "TEST.NFO")})
+	logName := filepath.Join(t.TempDir(), "undo.tsv")
+	var w bytes.Buffer
+	be.Err(t, fixExtensions(&w, []string{"-apply", "-log", logName, dir}), nil)
+	_, err := os.Stat(logName)
+	be.True(t, os.IsNotExist(err))
+	be.Err(t, fixExtensions(&w, nil), errNoDir)
+}
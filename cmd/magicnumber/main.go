@@ -2,26 +2,54 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/Defacto2/magicnumber"
 )
 
+const usage = `Usage:
+  magicnumber <path-to-file>
+  magicnumber explain <path-to-file>
+  magicnumber fix-extensions [-apply] <directory>
+  magicnumber stats [-json] <directory>
+  magicnumber serve [-addr host:port] [-max bytes]`
+
 func main() {
 	const minArgs = 2
 	if len(os.Args) < minArgs {
-		fmt.Fprintln(os.Stderr, "Usage: magicnumber <path-to-file>")
+		fmt.Fprintln(os.Stderr, usage)
 		os.Exit(1)
 	}
-
-	name := os.Args[1]
-	file, err := os.Open(name) //nolint:gosec
+	var err error
+	switch os.Args[1] {
+	case "explain":
+		err = explain(os.Stdout, os.Args[2:])
+	case "fix-extensions":
+		err = fixExtensions(os.Stdout, os.Args[2:])
+	case "stats":
+		err = stats(os.Stdout, os.Args[2:])
+	case "serve":
+		err = serve(os.Stdout, os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprintln(os.Stdout, usage)
+	default:
+		err = find(os.Stdout, os.Args[1])
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+}
+
+func find(w io.Writer, name string) error {
+	file, err := os.Open(name) //nolint:gosec
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
 	defer file.Close()
 
 	result := magicnumber.Find(file)
-	fmt.Fprintf(os.Stdout, "%s : %s %q\n", name, result, result.Title())
+	fmt.Fprintf(w, "%s : %s %q\n", name, result, result.Title())
+	return nil
 }
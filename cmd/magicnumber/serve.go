@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Defacto2/magicnumber/magichttp"
+)
+
+// serve runs the local HTTP file type detection service.
+func serve(w io.Writer, args []string) error {
+	fset := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fset.String("addr", "localhost:8080", "the address to listen on")
+	maxBytes := fset.Int64("max", magichttp.DefaultMaxBytes, "maximum size of a request body in bytes")
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: magicnumber serve [-addr host:port] [-max bytes]")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		return err //nolint:wrapcheck
+	}
+	const timeout = 30 * time.Second
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           &magichttp.Handler{MaxBytes: *maxBytes},
+		ReadHeaderTimeout: timeout,
+		ReadTimeout:       timeout,
+		WriteTimeout:      timeout,
+	}
+	fmt.Fprintf(w, "listening on http://%s, POST files to /detect or /detect/multipart\n", *addr)
+	if err := srv.ListenAndServe(); err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+	return nil
+}
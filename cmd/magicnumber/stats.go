@@ -0,0 +1,211 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/Defacto2/magicnumber"
+)
+
+// Audit is the composition of the files in a directory tree.
+type Audit struct {
+	Root       string          `json:"root"`
+	Files      int             `json:"files"`
+	Bytes      int64           `json:"bytes"`
+	Mismatches int             `json:"mismatches"`
+	Signatures []SignatureStat `json:"signatures"`
+	Categories []CategoryStat  `json:"categories"`
+	Mismatched []Mismatch      `json:"mismatched"`
+	Unreadable []Unreadable    `json:"unreadable"`
+}
+
+// SignatureStat is the total of the files that share a file type signature.
+type SignatureStat struct {
+	Signature  string `json:"signature"`
+	Title      string `json:"title"`
+	Category   string `json:"category"`
+	Count      int    `json:"count"`
+	Bytes      int64  `json:"bytes"`
+	Mismatches int    `json:"mismatches"`
+}
+
+// CategoryStat is the total of the files that share a category.
+type CategoryStat struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// Mismatch is a file with an extension that does not match the file type signature.
+type Mismatch struct {
+	Path       string   `json:"path"`
+	Signature  string   `json:"signature"`
+	Extensions []string `json:"expected_extensions"`
+}
+
+// Unreadable is a file or directory that could not be read, which is not counted by the audit.
+type Unreadable struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// stats walks a directory tree and prints the count and total bytes of the files
+// per file type signature and per category, and lists the extension mismatches.
+func stats(w io.Writer, args []string) error {
+	fset := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := fset.Bool("json", false, "print the audit as JSON")
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: magicnumber stats [-json] <directory>")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if fset.NArg() < 1 {
+		fset.Usage()
+		return errNoDir
+	}
+	audit, err := collect(fset.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(audit); err != nil {
+			return fmt.Errorf("stats json: %w", err)
+		}
+		return nil
+	}
+	return table(w, audit)
+}
+
+// collect walks the root directory and returns the audit of the files.
+func collect(root string) (Audit, error) {
+	audit := Audit{
+		Root:       root,
+		Signatures: []SignatureStat{},
+		Categories: []CategoryStat{},
+		Mismatched: []Mismatch{},
+		Unreadable: []Unreadable{},
+	}
+	signs := map[magicnumber.Signature]*SignatureStat{}
+	cats := map[magicnumber.Category]*CategoryStat{}
+	exts := *magicnumber.Ext()
+	// a file or a subdirectory that cannot be read is listed as unreadable and the walk continues
+	unreadable := func(path string, err error) {
+		audit.Unreadable = append(audit.Unreadable, Unreadable{Path: path, Error: err.Error()})
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d == nil || path == root {
+				return err
+			}
+			unreadable(path, err)
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			unreadable(path, err)
+			return nil
+		}
+		valid, sign, err := matchExt(path)
+		switch {
+		case errors.Is(err, magicnumber.ErrNilReader):
+			sign = magicnumber.ZeroByte
+		case err != nil:
+			unreadable(path, err)
+			return nil
+		}
+		size := info.Size()
+		audit.Files++
+		audit.Bytes += size
+		stat, ok := signs[sign]
+		if !ok {
+			stat = &SignatureStat{
+				Signature: sign.String(),
+				Title:     sign.Title(),
+				Category:  sign.Category().String(),
+			}
+			signs[sign] = stat
+		}
+		stat.Count++
+		stat.Bytes += size
+		cat, ok := cats[sign.Category()]
+		if !ok {
+			cat = &CategoryStat{Category: sign.Category().String()}
+			cats[sign.Category()] = cat
+		}
+		cat.Count++
+		cat.Bytes += size
+		if expected := exts[sign]; !valid && len(expected) > 0 {
+			stat.Mismatches++
+			audit.Mismatches++
+			audit.Mismatched = append(audit.Mismatched, Mismatch{
+				Path: path, Signature: sign.String(), Extensions: expected,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return audit, fmt.Errorf("stats walk %s: %w", root, err)
+	}
+	for _, stat := range signs {
+		audit.Signatures = append(audit.Signatures, *stat)
+	}
+	slices.SortFunc(audit.Signatures, func(a, b SignatureStat) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Signature, b.Signature))
+	})
+	for _, cat := range cats {
+		audit.Categories = append(audit.Categories, *cat)
+	}
+	slices.SortFunc(audit.Categories, func(a, b CategoryStat) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Category, b.Category))
+	})
+	return audit, nil
+}
+
+func table(w io.Writer, audit Audit) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "signature\tcategory\tfiles\tbytes\tmismatches")
+	for _, s := range audit.Signatures {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", s.Signature, s.Category, s.Count, s.Bytes, s.Mismatches)
+	}
+	fmt.Fprintln(tw, "\t\t\t\t")
+	fmt.Fprintln(tw, "category\t\tfiles\tbytes\t")
+	for _, c := range audit.Categories {
+		fmt.Fprintf(tw, "%s\t\t%d\t%d\t\n", c.Category, c.Count, c.Bytes)
+	}
+	fmt.Fprintln(tw, "\t\t\t\t")
+	fmt.Fprintf(tw, "total\t\t%d\t%d\t%d\n", audit.Files, audit.Bytes, audit.Mismatches)
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("stats flush: %w", err)
+	}
+	if len(audit.Mismatched) > 0 {
+		fmt.Fprintln(w, "\nextension mismatches")
+		for _, m := range audit.Mismatched {
+			fmt.Fprintf(w, "  %s  %s, expected %v\n", m.Path, m.Signature, m.Extensions)
+		}
+	}
+	if len(audit.Unreadable) > 0 {
+		fmt.Fprintln(w, "\nunreadable files")
+		for _, u := range audit.Unreadable {
+			fmt.Fprintf(w, "  %s  %s\n", u.Path, u.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nalgeon/be"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	t.Log("TestStats")
+	png, nfo := fixture(t, "TEST.PNG"), fixture(t, "TEST.NFO")
+	dir := tree(t, map[string][]byte{
+		"logo.png":   png,
+		"LOGO.GIF":   png,
+		"README.NFO": nfo,
+		"empty.txt":  nil,
+	})
+	audit, err := collect(dir)
+	be.Err(t, err, nil)
+	be.Equal(t, 4, audit.Files)
+	be.Equal(t, int64(2*len(png)+len(nfo)), audit.Bytes)
+	be.Equal(t, 1, audit.Mismatches)
+	be.Equal(t, []Mismatch{{
+		Path: filepath.Join(dir, "LOGO.GIF"), Signature: "PNG image", Extensions: []string{".png"},
+	}}, audit.Mismatched)
+	// the signatures and categories are sorted by the count of files
+	be.Equal(t, SignatureStat{
+		Signature: "PNG image", Title: "Portable Network Graphics", Category: "image",
+		Count: 2, Bytes: int64(2 * len(png)), Mismatches: 1,
+	}, audit.Signatures[0])
+	be.Equal(t, 3, len(audit.Signatures))
+	be.Equal(t, CategoryStat{Category: "image", Count: 2, Bytes: int64(2 * len(png))}, audit.Categories[0])
+
+	var w bytes.Buffer
+	be.Err(t, stats(&w, []string{"-json", dir}), nil)
+	var decoded Audit
+	be.Err(t, json.Unmarshal(w.Bytes(), &decoded), nil)
+	be.Equal(t, audit, decoded)
+
+	w.Reset()
+	be.Err(t, stats(&w, []string{dir}), nil)
+	be.True(t, strings.Contains(w.String(), "extension mismatches"))
+	be.True(t, strings.Contains(w.String(), "LOGO.GIF  PNG image, expected [.png]"))
+	be.Err(t, stats(&w, nil), errNoDir)
+}
+
+func TestStatsUnreadable(t *testing.T) {
+	t.Parallel()
+	t.Log("TestStatsUnreadable")
+	dir := tree(t, map[string][]byte{"logo.png": fixture(t, "TEST.PNG"), "secret.txt": []byte("hi")})
+	secret := filepath.Join(dir, "secret.txt")
+	be.Err(t, os.Chmod(secret, 0), nil)
+	if f, err := os.Open(secret); err == nil {
+		f.Close()
+		t.Skip("the user can read files without permissions")
+	}
+	// an unreadable file is listed and does not stop the audit
+	audit, err := collect(dir)
+	be.Err(t, err, nil)
+	be.Equal(t, 1, audit.Files)
+	be.Equal(t, 1, len(audit.Unreadable))
+	be.Equal(t, secret, audit.Unreadable[0].Path)
+	var w bytes.Buffer
+	be.Err(t, stats(&w, []string{dir}), nil)
+	be.True(t, strings.Contains(w.String(), "unreadable files"))
+}
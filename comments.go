@@ -0,0 +1,169 @@
+package magicnumber
+
+// Package file comments.go contains the functions that read the archive and file comments
+// of the archive formats, which often contain the adverts of the BBS that distributed the file.
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ArchiveComments are the comments of an archive.
+type ArchiveComments struct {
+	Archive string        // Archive is the archive comment, also known as the global comment
+	Files   []FileComment // Files are the file entries with a comment, in the stored order
+}
+
+// FileComment is the comment of a file entry of an archive.
+type FileComment struct {
+	Name    string // Name is the filename of the entry
+	Comment string // Comment is the comment of the entry
+}
+
+// Comments returns the archive comment and the file comments of a ZIP, ARJ,
+// RAR, LHA, Zoo or Gzip archive. The comments are decoded from IBM Code Page 437
+// to UTF-8 text, unless the archive format marks them as UTF-8.
+//
+// Compressed RAR comments cannot be read and are ignored.
+// Other archive and file types return no comments.
+func Comments(r io.ReaderAt) (ArchiveComments, error) {
+	if r == nil {
+		return ArchiveComments{}, ErrNilReader
+	}
+	sign, err := Archive(r)
+	if err != nil {
+		return ArchiveComments{}, err
+	}
+	var c ArchiveComments
+	switch sign {
+	case PKWAREZipShrink, PKWAREZipReduce, PKWAREZipImplode, PKWAREZip64, PKWAREZip, PKSFX:
+		c = zipComments(r)
+	case ArchiveRobertJung:
+		c = arjComments(r)
+	case RoshalARchive, RoshalARchivev5:
+		c = rarComments(r)
+	case YoshiLHA:
+		c = lhaComments(r)
+	case ZooArchive:
+		c = zooComments(r)
+	case GzipCompressArchive:
+		c = gzipComments(r)
+	}
+	if c.Files == nil {
+		c.Files = []FileComment{}
+	}
+	return c, nil
+}
+
+// DecodeCP437 returns the IBM Code Page 437 text as UTF-8.
+// Any trailing NUL and end-of-file (0x1a) control characters are removed.
+func DecodeCP437(p []byte) string {
+	p = bytes.TrimRight(p, "\x00\x1a")
+	s, err := charmap.CodePage437.NewDecoder().Bytes(p)
+	if err != nil {
+		return string(p)
+	}
+	return string(s)
+}
+
+func (c *ArchiveComments) add(name, comment string) {
+	if strings.TrimSpace(comment) == "" {
+		return
+	}
+	c.Files = append(c.Files, FileComment{Name: name, Comment: comment})
+}
+
+func zipComments(r io.ReaderAt) ArchiveComments {
+	var c ArchiveComments
+	end, err := ZipEndRecord(r)
+	if err != nil {
+		return c
+	}
+	c.Archive = DecodeCP437([]byte(end.Comment))
+	if utf8.ValidString(end.Comment) && !isASCII(end.Comment) {
+		// the archive comment has no encoding flag, so valid UTF-8 is assumed to be UTF-8
+		c.Archive = strings.TrimRight(end.Comment, "\x00\x1a")
+	}
+	entries, err := ZipEntries(r)
+	if err != nil {
+		return c
+	}
+	for _, e := range entries {
+		if e.Comment == "" {
+			continue
+		}
+		// the file comment uses the same encoding as the filename
+		enc := e.Encoding()
+		if enc == ZipASCII || enc == ZipUnicodePath {
+			enc = ZipCP437
+		}
+		c.add(e.DecodedName(), strings.TrimRight(enc.Decode([]byte(e.Comment)), "\x00\x1a"))
+	}
+	return c
+}
+
+func isASCII(s string) bool {
+	for i := range len(s) {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// arjComments returns the comment of the main header and the comments of the file headers of an ARJ archive.
+func arjComments(r io.ReaderAt) ArchiveComments {
+	arc, _ := ArjHeaders(r)
+	c := ArchiveComments{Archive: arc.Comment}
+	for _, file := range arc.Files {
+		c.add(file.Name, file.Comment)
+	}
+	return c
+}
+
+// rarComments returns the stored archive comment of a RAR v4 or v5 archive,
+// either the comment block of RAR v2, the CMT sub-block of RAR v3 or the CMT service header of RAR v5.
+func rarComments(r io.ReaderAt) ArchiveComments {
+	arc, _ := RarHeaders(r)
+	return ArchiveComments{Archive: arc.Comment}
+}
+
+// lhaComments returns the file comments of an LHA archive, either the Amiga LhA comment
+// that follows the filename of the level 0 and 1 headers or the comment extended header.
+func lhaComments(r io.ReaderAt) ArchiveComments {
+	var c ArchiveComments
+	entries, _ := LhaHeaders(r)
+	for _, e := range entries {
+		c.add(e.Path, e.Comment)
+	}
+	return c
+}
+
+// zooComments reads the archive comment and the comments of the directory entries
+// that are not deleted.
+func zooComments(r io.ReaderAt) ArchiveComments {
+	var c ArchiveComments
+	dir, _ := ZooHeaders(r)
+	c.Archive = dir.Comment
+	for _, e := range dir.Entries {
+		if !e.Deleted {
+			c.add(e.Path(), e.Comment)
+		}
+	}
+	return c
+}
+
+// gzipComments reads the comment of the first member header of a Gzip archive.
+func gzipComments(r io.ReaderAt) ArchiveComments {
+	var c ArchiveComments
+	member, _, err := gzipHeader(r, 0)
+	if err != nil {
+		return c
+	}
+	c.Archive = member.Comment
+	return c
+}
@@ -0,0 +1,180 @@
+package magicnumber_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+const advert = "\xda\xc4 Call The BBS \xc4\xbf"
+
+func TestComments(t *testing.T) {
+	t.Parallel()
+	t.Log("TestComments")
+	_, err := magicnumber.Comments(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	for _, name := range []string{arjFile, lhaFile, rarFile, rarv5File, zooFile, zipShrinkFile, gzFile, cabFile} {
+		r, err := os.Open(tdfile(name))
+		be.Err(t, err, nil)
+		c, err := magicnumber.Comments(r)
+		be.Err(t, err, nil)
+		be.Equal(t, "", c.Archive)
+		be.Equal(t, 0, len(c.Files))
+		r.Close()
+	}
+	be.Equal(t, "┌─ Call The BBS ─┐", magicnumber.DecodeCP437([]byte(advert+"\x00\x1a")))
+}
+
+func TestCommentsZip(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCommentsZip")
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, fh := range []*zip.FileHeader{
+		{Name: "FILE_ID.DIZ", Comment: advert},
+		{Name: "README.TXT"},
+	} {
+		w, err := zw.CreateHeader(fh)
+		be.Err(t, err, nil)
+		_, err = w.Write([]byte("hello world"))
+		be.Err(t, err, nil)
+	}
+	be.Err(t, zw.SetComment(advert), nil)
+	be.Err(t, zw.Close(), nil)
+	c, err := magicnumber.Comments(bytes.NewReader(buf.Bytes()))
+	be.Err(t, err, nil)
+	be.Equal(t, "┌─ Call The BBS ─┐", c.Archive)
+	be.Equal(t, []magicnumber.FileComment{{Name: "FILE_ID.DIZ", Comment: "┌─ Call The BBS ─┐"}}, c.Files)
+}
+
+func TestCommentsArj(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCommentsArj")
+	header := func(fileType byte, name, comment string, data []byte) []byte {
+		const firstLen = 30
+		basic := make([]byte, firstLen)
+		basic[0] = firstLen
+		basic[6] = fileType
+		binary.LittleEndian.PutUint32(basic[12:], uint32(len(data)))
+		basic = append(basic, name+"\x00"+comment+"\x00"...)
+		p := []byte{0x60, 0xea, 0, 0}
+		binary.LittleEndian.PutUint16(p[2:], uint16(len(basic)))
+		p = append(p, basic...)
+		p = append(p, 0, 0, 0, 0, 0, 0) // basic header crc and no extended header
+		return append(p, data...)
+	}
+	arj := header(2, "TEST.ARJ", advert, nil)
+	arj = append(arj, header(0, "README.TXT", "", []byte("hello"))...)
+	arj = append(arj, header(0, "FILE_ID.DIZ", "a file comment", []byte("world"))...)
+	arj = append(arj, 0x60, 0xea, 0, 0)
+	c, err := magicnumber.Comments(bytes.NewReader(arj))
+	be.Err(t, err, nil)
+	be.Equal(t, "┌─ Call The BBS ─┐", c.Archive)
+	be.Equal(t, []magicnumber.FileComment{{Name: "FILE_ID.DIZ", Comment: "a file comment"}}, c.Files)
+}
+
+func TestCommentsRar(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCommentsRar")
+	// a RAR v2 archive header with a stored comment block
+	comment := []byte{0, 0, 0x75, 0, 0, 0, 0, byte(len(advert)), 0, 0x14, 0x30, 0, 0}
+	binary.LittleEndian.PutUint16(comment[5:], uint16(13+len(advert)))
+	comment = append(comment, advert...)
+	main := []byte{0, 0, 0x73, 0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(main[5:], uint16(len(main)+len(comment)))
+	rar := append([]byte("Rar!\x1a\x07\x00"), main...)
+	rar = append(rar, comment...)
+	rar = append(rar, 0, 0, 0x7b, 0, 0x40, 7, 0)
+	c, err := magicnumber.Comments(bytes.NewReader(rar))
+	be.Err(t, err, nil)
+	be.Equal(t, "┌─ Call The BBS ─┐", c.Archive)
+
+	// a RAR v3 archive with a stored CMT sub-block
+	sub := make([]byte, 32, 35)
+	sub[2] = 0x7a
+	binary.LittleEndian.PutUint16(sub[3:], 0x8000)
+	binary.LittleEndian.PutUint16(sub[5:], 35)
+	binary.LittleEndian.PutUint32(sub[7:], uint32(len(advert)))
+	sub[25] = 0x30
+	binary.LittleEndian.PutUint16(sub[26:], 3)
+	sub = append(sub, "CMT"...)
+	rar3 := append([]byte("Rar!\x1a\x07\x00"), 0, 0, 0x73, 0, 0, 13, 0, 0, 0, 0, 0, 0, 0)
+	rar3 = append(rar3, sub...)
+	rar3 = append(rar3, advert...)
+	rar3 = append(rar3, 0, 0, 0x7b, 0, 0x40, 7, 0)
+	arc, err := magicnumber.RarHeaders(bytes.NewReader(rar3))
+	be.Err(t, err, nil)
+	be.Equal(t, "┌─ Call The BBS ─┐", arc.Comment)
+	c, err = magicnumber.Comments(bytes.NewReader(rar3))
+	be.Err(t, err, nil)
+	be.Equal(t, arc.Comment, c.Archive)
+
+	// a RAR v5 archive with a stored CMT service header
+	text := "Call the BBS ☎"
+	head := []byte{3, 0x2, byte(len(text)), 0, byte(len(text)), 0, 0, 0, 3}
+	head = append(head, "CMT"...)
+	rar5 := append([]byte("Rar!\x1a\x07\x01\x00"), 0, 0, 0, 0, byte(len(head)))
+	rar5 = append(rar5, head...)
+	rar5 = append(rar5, text...)
+	rar5 = append(rar5, 0, 0, 0, 0, 3, 5, 0, 0)
+	c, err = magicnumber.Comments(bytes.NewReader(rar5))
+	be.Err(t, err, nil)
+	be.Equal(t, text, c.Archive)
+}
+
+func TestCommentsLha(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCommentsLha")
+	// a level 0 header with an Amiga LhA file comment after the filename
+	name := "FILE_ID.DIZ\x00" + advert
+	head := make([]byte, 22)
+	copy(head[2:], "-lh0-")
+	binary.LittleEndian.PutUint32(head[7:], 5)
+	head[21] = byte(len(name))
+	head = append(head, name...)
+	head = append(head, 0, 0) // crc
+	head[0] = byte(len(head) - 2)
+	lha := append(head, "hello"...)
+	lha = append(lha, 0)
+	c, err := magicnumber.Comments(bytes.NewReader(lha))
+	be.Err(t, err, nil)
+	be.Equal(t, []magicnumber.FileComment{{Name: "FILE_ID.DIZ", Comment: "┌─ Call The BBS ─┐"}}, c.Files)
+}
+
+func TestCommentsZoo(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCommentsZoo")
+	zoo, err := os.ReadFile(tdfile(zooFile))
+	be.Err(t, err, nil)
+	// point the archive comment and the comment of the first entry to existing text
+	const entry = 42
+	binary.LittleEndian.PutUint32(zoo[35:], 4)
+	binary.LittleEndian.PutUint16(zoo[39:], 12)
+	binary.LittleEndian.PutUint32(zoo[entry+32:], 13)
+	binary.LittleEndian.PutUint16(zoo[entry+36:], 3)
+	c, err := magicnumber.Comments(bytes.NewReader(zoo))
+	be.Err(t, err, nil)
+	be.Equal(t, "2.10 Archive", c.Archive)
+	be.Equal(t, []magicnumber.FileComment{{Name: "24mhzhck.txt", Comment: "ive"}}, c.Files)
+}
+
+func TestCommentsGzip(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCommentsGzip")
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	zw.Name = "readme.txt"
+	zw.Comment = "Café BBS"
+	_, err := zw.Write([]byte("hello world"))
+	be.Err(t, err, nil)
+	be.Err(t, zw.Close(), nil)
+	c, err := magicnumber.Comments(bytes.NewReader(buf.Bytes()))
+	be.Err(t, err, nil)
+	be.Equal(t, "Café BBS", c.Archive)
+}
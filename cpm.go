@@ -0,0 +1,63 @@
+package magicnumber
+
+// Package file cpm.go contains the functions that read the headers of the CP/M era
+// Squeeze, Crunch and CRLZH compression formats.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrCPMHeader = errors.New("cp/m compression header is invalid")
+
+// cpmNameLen is the maximum length of the original filename, including the
+// optional date stamp or comment in square brackets that Crunch can append.
+const cpmNameLen = 80
+
+// CPMFilename returns the original filename that is stored in the header of
+// a Squeezed, Crunched or CRLZH compressed file. These formats were first used on CP/M
+// and later MS-DOS, and replace the middle letter of the file extension with Q, Z or Y.
+//
+// Crunch and CRLZH can store a date stamp or comment in square brackets after the filename,
+// which is not returned.
+func CPMFilename(r io.ReaderAt) (string, error) {
+	if r == nil {
+		return "", ErrNilReader
+	}
+	const squeezeOffset, crunchOffset = 4, 2
+	offset := 0
+	switch {
+	case Squeeze(r):
+		offset = squeezeOffset
+	case Crunch(r), CrunchLZH(r):
+		offset = crunchOffset
+	default:
+		return "", fmt.Errorf("%w: no signature", ErrCPMHeader)
+	}
+	p := make([]byte, offset+cpmNameLen+1)
+	n, _ := r.ReadAt(p, 0)
+	name, ok := cpmName(p[offset:n])
+	if !ok {
+		return "", fmt.Errorf("%w: filename at %d", ErrCPMHeader, offset)
+	}
+	name, _, _ = strings.Cut(name, "[")
+	return strings.TrimSpace(name), nil
+}
+
+// cpmName returns the NUL terminated filename at the start of p,
+// and false if the filename is empty, too long or contains non-printable characters.
+func cpmName(p []byte) (string, bool) {
+	name, _, found := bytes.Cut(p, []byte{0})
+	if !found || len(name) == 0 || len(name) > cpmNameLen {
+		return "", false
+	}
+	for _, c := range name {
+		if c < ' ' || c > '~' {
+			return "", false
+		}
+	}
+	return string(name), true
+}
@@ -0,0 +1,65 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestCPMFilename(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCPMFilename")
+	_, err := magicnumber.CPMFilename(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	tests := []struct {
+		name string
+		b    []byte
+		sign magicnumber.Signature
+		want string
+	}{
+		{"squeeze", []byte("\x76\xff\x34\x12README.TXT\x00\x01\x00"), magicnumber.CPMSqueeze, "README.TXT"},
+		{"crunch", []byte("\x76\xfeFILE.DOC\x00\x20\x20\x01\x00"), magicnumber.CPMCrunch, "FILE.DOC"},
+		{"crunch stamp", []byte("\x76\xfeBYE.ASM[05/12/86]\x00\x20\x20\x01\x00"), magicnumber.CPMCrunch, "BYE.ASM"},
+		{"crlzh", []byte("\x76\xfdMODEM.COM\x00\x20\x20\x01\x00"), magicnumber.CPMCrunchLZH, "MODEM.COM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := bytes.NewReader(tt.b)
+			be.Equal(t, tt.sign, magicnumber.Find(r))
+			sign, err := magicnumber.Archive(r)
+			be.Err(t, err, nil)
+			be.Equal(t, tt.sign, sign)
+			name, err := magicnumber.CPMFilename(r)
+			be.Err(t, err, nil)
+			be.Equal(t, tt.want, name)
+		})
+	}
+	// a filename without a terminator or with control characters is invalid
+	for _, s := range []string{"\x76\xffab" + strings.Repeat("X", 100), "\x76\xfeBAD\x07NAME\x00", "\x76\xfe\x00"} {
+		be.True(t, !magicnumber.Squeeze(strings.NewReader(s)))
+		be.True(t, !magicnumber.Crunch(strings.NewReader(s)))
+		_, err = magicnumber.CPMFilename(strings.NewReader(s))
+		be.Err(t, err, magicnumber.ErrCPMHeader)
+	}
+}
+
+func TestLbr(t *testing.T) {
+	t.Parallel()
+	t.Log("TestLbr")
+	// a directory of 1 sector with the directory entry and an entry for a file
+	b := make([]byte, 128)
+	copy(b, "\x00           \x00\x00\x01\x00")
+	copy(b[32:], "\x00README  TXT\x01\x00\x02\x00")
+	for i := 64; i < len(b); i += 32 {
+		b[i] = 0xff
+	}
+	r := bytes.NewReader(b)
+	be.True(t, magicnumber.Lbr(r))
+	be.Equal(t, magicnumber.CPMLibrary, magicnumber.Find(r))
+	b[13] = 1
+	be.True(t, !magicnumber.Lbr(bytes.NewReader(b)))
+}
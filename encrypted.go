@@ -0,0 +1,150 @@
+package magicnumber
+
+// Package file encrypted.go contains the functions that detect password protected
+// or encrypted content in archives and documents.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+)
+
+// Encryption is the encryption method of the content in an archive or document.
+type Encryption int
+
+const (
+	NotEncrypted Encryption = iota // NotEncrypted content, or the format is not supported
+	ZipCrypto                      // ZipCrypto is the traditional PKWARE zip encryption
+	ZipAES                         // ZipAES is the WinZip AES zip encryption
+	RARFiles                       // RARFiles are RAR archive entries encrypted with a password
+	RARHeaders                     // RARHeaders is a RAR archive with encrypted headers that hide the filenames
+	SevenZipAES                    // SevenZipAES is the 7-Zip AES-256 encryption
+	ARJGarbled                     // ARJGarbled is an ARJ archive garbled with a password
+	PDFEncrypt                     // PDFEncrypt is a PDF document with an encryption dictionary
+)
+
+// String returns the name of the encryption method.
+func (e Encryption) String() string {
+	if e < NotEncrypted || e > PDFEncrypt {
+		return ""
+	}
+	return [...]string{
+		"not encrypted",
+		"ZipCrypto",
+		"WinZip AES",
+		"RAR encrypted files",
+		"RAR encrypted headers",
+		"7-Zip AES",
+		"ARJ garbled",
+		"PDF encryption",
+	}[e]
+}
+
+// Encrypted returns the encryption method used by the archive or document, which
+// means the content cannot be previewed or extracted without a password.
+// It recognises ZIP, RAR v4 and v5, 7-Zip, ARJ archives and PDF documents,
+// any other file type returns NotEncrypted.
+func Encrypted(r io.ReaderAt) (Encryption, error) {
+	if r == nil {
+		return NotEncrypted, ErrNilReader
+	}
+	switch {
+	case pkzip(r) != pkNone, Zip64(r):
+		return zipEncrypted(r), nil
+	case Rar(r), Rarv5(r):
+		return rarEncrypted(r), nil
+	case X7z(r):
+		return x7zEncrypted(r), nil
+	case Arj(r):
+		return arjEncrypted(r), nil
+	case Pdf(r):
+		return pdfEncrypted(r), nil
+	}
+	return NotEncrypted, nil
+}
+
+// zipEncrypted returns the encryption of the entries in the central directory,
+// or the first local file header when the central directory cannot be read.
+func zipEncrypted(r io.ReaderAt) Encryption {
+	const encrypted, aes = 0x1, 0x63
+	entries, err := ZipEntries(r)
+	if err != nil {
+		const size = 30
+		p := make([]byte, size)
+		if n, err := r.ReadAt(p, 0); err != nil || n < size {
+			return NotEncrypted
+		}
+		entries = []ZipEntry{{
+			Flags:  binary.LittleEndian.Uint16(p[6:]),
+			Method: ZipMethod(binary.LittleEndian.Uint16(p[8:])),
+		}}
+	}
+	for _, e := range entries {
+		if e.Flags&encrypted == 0 {
+			continue
+		}
+		if e.Method == aes {
+			return ZipAES
+		}
+		return ZipCrypto
+	}
+	return NotEncrypted
+}
+
+// rarEncrypted returns the encryption of the archive headers or the file headers of a RAR v4 or v5 archive.
+func rarEncrypted(r io.ReaderAt) Encryption {
+	arc, _ := RarHeaders(r)
+	if arc.EncryptedHeaders {
+		return RARHeaders
+	}
+	for _, entry := range arc.Entries {
+		if entry.Encrypted {
+			return RARFiles
+		}
+	}
+	return NotEncrypted
+}
+
+// x7zEncrypted returns SevenZipAES if the header or the packed streams of a 7-Zip archive use the AES-256 + SHA-256 coder.
+func x7zEncrypted(r io.ReaderAt) Encryption {
+	arc, _ := X7zHeaders(r)
+	if arc.EncryptedHeader || slices.Contains(arc.Coders, "AES") {
+		return SevenZipAES
+	}
+	return NotEncrypted
+}
+
+// arjEncrypted returns ARJGarbled if the main header or any file header has the garbled flag.
+func arjEncrypted(r io.ReaderAt) Encryption {
+	arc, _ := ArjHeaders(r)
+	if arc.Garbled {
+		return ARJGarbled
+	}
+	for _, file := range arc.Files {
+		if file.Garbled {
+			return ARJGarbled
+		}
+	}
+	return NotEncrypted
+}
+
+// pdfEncrypted searches the start and end of a PDF document for the /Encrypt
+// key of the trailer dictionary or the cross-reference stream dictionary.
+func pdfEncrypted(r io.ReaderAt) Encryption {
+	const size = 64 << 10
+	length := Length(r)
+	offsets := []int64{0}
+	if length > size {
+		offsets = append(offsets, length-size)
+	}
+	key := []byte("/Encrypt")
+	for _, offset := range offsets {
+		p := make([]byte, size)
+		n, _ := r.ReadAt(p, offset)
+		if bytes.Contains(p[:n], key) {
+			return PDFEncrypt
+		}
+	}
+	return NotEncrypted
+}
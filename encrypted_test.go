@@ -0,0 +1,108 @@
+package magicnumber_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestEncrypted(t *testing.T) {
+	t.Parallel()
+	t.Log("TestEncrypted")
+	enc, err := magicnumber.Encrypted(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	be.Equal(t, magicnumber.NotEncrypted, enc)
+
+	tests := []struct {
+		name string
+		want magicnumber.Encryption
+	}{
+		{"τεχτƒιℓε.encrypted.zip", magicnumber.ZipAES},
+		{"τεχτƒιℓε.zip", magicnumber.NotEncrypted},
+		{zipShrinkFile, magicnumber.NotEncrypted},
+		{rarFile, magicnumber.NotEncrypted},
+		{rarv5File, magicnumber.NotEncrypted},
+		{x7zFile, magicnumber.NotEncrypted},
+		{arjFile, magicnumber.NotEncrypted},
+		{lhaFile, magicnumber.NotEncrypted},
+	}
+	for _, tt := range tests {
+		r, err := os.Open(tdfile(tt.name))
+		be.Err(t, err, nil)
+		enc, err := magicnumber.Encrypted(r)
+		be.Err(t, err, nil)
+		be.Equal(t, tt.want, enc)
+		r.Close()
+	}
+	be.Equal(t, "WinZip AES", magicnumber.ZipAES.String())
+}
+
+func TestEncryptedZipCrypto(t *testing.T) {
+	t.Parallel()
+	t.Log("TestEncryptedZipCrypto")
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, fh := range []*zip.FileHeader{
+		{Name: "readme.txt", Method: zip.Deflate},
+		{Name: "secret.txt", Method: zip.Deflate, Flags: 0x1},
+	} {
+		w, err := zw.CreateHeader(fh)
+		be.Err(t, err, nil)
+		_, err = w.Write([]byte("hello world"))
+		be.Err(t, err, nil)
+	}
+	be.Err(t, zw.Close(), nil)
+	enc, err := magicnumber.Encrypted(bytes.NewReader(buf.Bytes()))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.ZipCrypto, enc)
+}
+
+func TestEncryptedFlags(t *testing.T) {
+	t.Parallel()
+	t.Log("TestEncryptedFlags")
+	rar, err := os.ReadFile(tdfile(rarFile))
+	be.Err(t, err, nil)
+	// the flags of the last file header
+	p := bytes.Clone(rar)
+	p[63737+3] |= 0x04
+	enc, err := magicnumber.Encrypted(bytes.NewReader(p))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.RARFiles, enc)
+	// the flags of the archive header
+	p = bytes.Clone(rar)
+	p[7+3] |= 0x80
+	enc, err = magicnumber.Encrypted(bytes.NewReader(p))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.RARHeaders, enc)
+
+	arj, err := os.ReadFile(tdfile(arjFile))
+	be.Err(t, err, nil)
+	// the flags of the last file header
+	arj[765248] |= 0x01
+	enc, err = magicnumber.Encrypted(bytes.NewReader(arj))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.ARJGarbled, enc)
+
+	// an encoded next header that uses the AES-256 + SHA-256 coder
+	next := []byte{0x17,
+		0x06, 0x00, 0x01, 0x09, 0x10, 0x00,
+		0x07, 0x0b, 0x01, 0x00, 0x01, 0x24, 0x06, 0xf1, 0x07, 0x01, 0x00, 0x0c, 0x10, 0x00,
+		0x00,
+	}
+	enc, err = magicnumber.Encrypted(bytes.NewReader(x7zArchive(make([]byte, 16), next)))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.SevenZipAES, enc)
+
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<< /Root 1 0 R /Encrypt 2 0 R >>\n%%EOF\n")
+	enc, err = magicnumber.Encrypted(bytes.NewReader(pdf))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.PDFEncrypt, enc)
+	pdf = []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	enc, err = magicnumber.Encrypted(bytes.NewReader(pdf))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.NotEncrypted, enc)
+}
@@ -25,6 +25,7 @@ func Pklite(r io.ReaderAt) bool {
 
 // Pksfx matches the PKSFX archive format in the byte slice which is a
 // self-extracting archive format.
+// The self-extractors of other archivers are found by [SelfExtractor].
 func Pksfx(r io.ReaderAt) bool {
 	const size = 5
 	const offset = 526
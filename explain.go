@@ -0,0 +1,159 @@
+package magicnumber
+
+// Package file explain.go contains the functions that report how a file type signature was found.
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Evidence is a range of bytes in the reader that was read by a matcher.
+type Evidence struct {
+	Offset int64 // Offset is the position of the first byte read
+	Length int64 // Length is the number of bytes read
+}
+
+// Step is the result of a single matcher or text heuristic tried by [Explain].
+type Step struct {
+	Name      string     // Name of the matcher or text heuristic function, for example "Pkzip" or "TxtW"
+	Signature Signature  // Signature is the file type returned when the step matches
+	Matched   bool       // Matched is true if the matcher or heuristic matched the reader
+	Evidence  []Evidence // Evidence are the merged byte ranges read by the step
+}
+
+// Explanation is the report returned by [Explain].
+type Explanation struct {
+	Result Signature // Result is the file type signature returned by Find
+	Steps  []Step    // Steps are the matchers and text heuristics in precedence order
+	Text   TextStats // Text are the statistics used by the text heuristics
+}
+
+// Explain reads the reader and returns an explanation of how [Find] identifies the file type.
+//
+// Unlike Find, which stops at the first match, every matcher listed in [Precedence] is tried
+// followed by the AnsiW, CodePageW and TxtW text heuristics. This makes it possible to
+// see all the signatures that could match a misidentified file and the bytes used as evidence.
+func Explain(r io.ReaderAt) (Explanation, error) {
+	explain := Explanation{
+		Result: ZeroByte,
+	}
+	if r == nil {
+		return explain, ErrNilReader
+	}
+	if Empty(r) {
+		return explain, nil
+	}
+	explain.Result = Unknown
+	matchers := *New()
+	for _, sign := range Precedence() {
+		matcher, exists := matchers[sign]
+		if !exists {
+			continue
+		}
+		step := try(r, funcName(matcher), sign, matcher)
+		if step.Matched && explain.Result == Unknown {
+			explain.Result = sign
+		}
+		explain.Steps = append(explain.Steps, step)
+	}
+	heuristics := []struct {
+		name    string
+		sign    Signature
+		matcher Matcher
+	}{
+		{"AnsiW", ANSIEscapeText, Ansi},
+		{"CodePageW", PlainText, CodePage},
+		{"TxtW", PlainText, Txt},
+	}
+	for _, h := range heuristics {
+		step := try(r, h.name, h.sign, h.matcher)
+		if step.Matched && explain.Result == Unknown {
+			explain.Result = h.sign
+		}
+		explain.Steps = append(explain.Steps, step)
+	}
+	explain.Text = TextStatistics(r)
+	return explain, nil
+}
+
+// try runs the matcher against a recording of the reader.
+func try(r io.ReaderAt, name string, sign Signature, matcher Matcher) Step {
+	rec := &recorder{r: r}
+	matched := matcher(rec)
+	return Step{
+		Name:      name,
+		Signature: sign,
+		Matched:   matched,
+		Evidence:  merge(rec.reads),
+	}
+}
+
+// funcName returns the unqualified name of the matcher function.
+func funcName(matcher Matcher) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(matcher).Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "."); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// merge sorts and combines the overlapping and adjacent byte ranges.
+func merge(reads []Evidence) []Evidence {
+	if len(reads) == 0 {
+		return nil
+	}
+	slices.SortFunc(reads, func(a, b Evidence) int {
+		switch {
+		case a.Offset < b.Offset:
+			return -1
+		case a.Offset > b.Offset:
+			return 1
+		}
+		return 0
+	})
+	merged := []Evidence{reads[0]}
+	for _, read := range reads[1:] {
+		last := &merged[len(merged)-1]
+		if read.Offset > last.Offset+last.Length {
+			merged = append(merged, read)
+			continue
+		}
+		if end := read.Offset + read.Length; end > last.Offset+last.Length {
+			last.Length = end - last.Offset
+		}
+	}
+	return merged
+}
+
+var errSeeker = errors.New("reader is not a seeker")
+
+// recorder is a reader that keeps a record of the byte ranges that have been read.
+// It also passes through the seeker of the underlying reader, as it is used by [Length].
+type recorder struct {
+	r     io.ReaderAt
+	reads []Evidence
+}
+
+func (rec *recorder) ReadAt(p []byte, off int64) (int, error) {
+	n, err := rec.r.ReadAt(p, off)
+	if n > 0 {
+		rec.reads = append(rec.reads, Evidence{Offset: off, Length: int64(n)})
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (rec *recorder) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := rec.r.(io.Seeker)
+	if !ok {
+		return 0, errSeeker
+	}
+	return seeker.Seek(offset, whence) //nolint:wrapcheck
+}
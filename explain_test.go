@@ -0,0 +1,77 @@
+package magicnumber_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestPrecedence(t *testing.T) {
+	t.Parallel()
+	t.Log("TestPrecedence")
+	order := magicnumber.Precedence()
+	finds := *magicnumber.New()
+	be.Equal(t, len(finds), len(order))
+	seen := map[magicnumber.Signature]bool{}
+	for _, sign := range order {
+		_, exists := finds[sign]
+		be.True(t, exists)
+		be.True(t, !seen[sign])
+		seen[sign] = true
+	}
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+	t.Log("TestExplain")
+	_, err := magicnumber.Explain(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(pakFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	explain, err := magicnumber.Explain(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.NoGatePAK, explain.Result)
+	be.Equal(t, magicnumber.Find(r), explain.Result)
+	matches := 0
+	for _, step := range explain.Steps {
+		if step.Name == "Pak" || step.Name == "ArcSEA" {
+			be.True(t, len(step.Evidence) > 0)
+			be.Equal(t, int64(0), step.Evidence[0].Offset)
+			if step.Matched {
+				matches++
+			}
+		}
+	}
+	be.Equal(t, 1, matches)
+	// every signature is tried once, including XBin that is listed by Precedence
+	tried := map[magicnumber.Signature]int{}
+	for _, step := range explain.Steps {
+		if step.Name != "CodePageW" && step.Name != "TxtW" {
+			tried[step.Signature]++
+		}
+	}
+	be.Equal(t, 1, tried[magicnumber.XBinaryText])
+
+	r, err = os.Open(tdfile(tarFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	explain, err = magicnumber.Explain(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.TapeARchive, explain.Result)
+	for _, step := range explain.Steps {
+		if step.Name == "Tar" {
+			be.Equal(t, []magicnumber.Evidence{{Offset: 0, Length: 512}}, step.Evidence)
+		}
+	}
+
+	explain, err = magicnumber.Explain(strings.NewReader("hello world"))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.PlainText, explain.Result)
+	be.Equal(t, int64(11), explain.Text.Size)
+	be.Equal(t, int64(-1), explain.Text.EscapeOffset)
+}
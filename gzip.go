@@ -0,0 +1,224 @@
+package magicnumber
+
+// Package file gzip.go contains the functions that parse the member headers of the Gzip compression format.
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var ErrGzipHeader = errors.New("gzip header is invalid")
+
+// GzipMember is a member of a Gzip file, most files have a single member,
+// but concatenated Gzip files have one member for each part.
+type GzipMember struct {
+	Name           string         // Name is the original filename, decoded from ISO 8859-1
+	Comment        string         // Comment is the file comment, decoded from ISO 8859-1
+	OS             string         // OS is the operating system of the file system that created the member
+	Extra          []GzipSubfield // Extra are the subfields of the extra field
+	Modified       time.Time      // Modified is the modification time of the original file, or zero when not stored
+	Offset         int64          // Offset is the position of the member header
+	CompressedSize int64          // CompressedSize is the size of the deflate data
+	Size           int64          // Size is the ISIZE trailer, the uncompressed size modulo 2^32
+	CRC32          uint32         // CRC32 is the checksum of the uncompressed data
+	Level          GzipLevel      // Level is the extra flags that describe the deflate compression level
+	Text           bool           // Text is true if the uncompressed data is probably text
+}
+
+// GzipSubfield is a subfield of the extra field, which has a two letter identifier.
+type GzipSubfield struct {
+	ID   string // ID is the two byte subfield identifier, such as "AP" for Apollo file type information
+	Data []byte // Data is the content of the subfield
+}
+
+// GzipLevel is the extra flags byte of the member header.
+type GzipLevel byte
+
+// String returns the description of the compression level.
+func (l GzipLevel) String() string {
+	switch l {
+	case 2:
+		return "maximum compression"
+	case 4:
+		return "fastest compression"
+	}
+	return ""
+}
+
+// GzipMembers parses the headers of the Gzip members and returns them in the stored order.
+// The deflate data of each member is decompressed to find the trailer and the start of the
+// next member, and an error is returned if the checksum or the size of the data is invalid.
+// Any data after the last member that is not a member header is ignored.
+func GzipMembers(r io.ReaderAt) ([]GzipMember, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	const trailerLen = 8
+	members := []GzipMember{}
+	offset := int64(0)
+	for range maxBlocks {
+		member, data, err := gzipHeader(r, offset)
+		if err != nil {
+			if len(members) > 0 {
+				return members, nil
+			}
+			return nil, err
+		}
+		cr := &countReader{r: bufio.NewReader(io.NewSectionReader(r, data, 1<<62))}
+		fr := flate.NewReader(cr)
+		crc := crc32.NewIEEE()
+		n, err := io.Copy(crc, fr)
+		fr.Close()
+		if err != nil {
+			return members, fmt.Errorf("%w: deflate data at %d: %w", ErrGzipHeader, data, err)
+		}
+		member.CompressedSize = cr.n
+		p := make([]byte, trailerLen)
+		if _, err := r.ReadAt(p, data+cr.n); err != nil {
+			return members, fmt.Errorf("%w: no trailer at %d", ErrGzipHeader, data+cr.n)
+		}
+		member.CRC32 = binary.LittleEndian.Uint32(p)
+		member.Size = int64(binary.LittleEndian.Uint32(p[4:]))
+		if member.CRC32 != crc.Sum32() || member.Size != n&0xffffffff {
+			return members, fmt.Errorf("%w: trailer checksum at %d", ErrGzipHeader, data+cr.n)
+		}
+		members = append(members, member)
+		offset = data + cr.n + trailerLen
+	}
+	return members, nil
+}
+
+// gzipHeader parses the member header at the offset and returns the member and the offset of the deflate data.
+func gzipHeader(r io.ReaderAt, offset int64) (GzipMember, int64, error) {
+	const (
+		ftext     = 0x01
+		fhcrc     = 0x02
+		fextra    = 0x04
+		fname     = 0x08
+		fcomment  = 0x10
+		reserved  = 0xe0
+		headerLen = 10
+		maxLen    = 64 << 10
+	)
+	p := make([]byte, headerLen)
+	if n, _ := r.ReadAt(p, offset); n < headerLen || p[0] != 0x1f || p[1] != 0x8b {
+		return GzipMember{}, 0, fmt.Errorf("%w: no member at %d", ErrGzipHeader, offset)
+	}
+	const deflate = 8
+	flags := p[3]
+	if p[2] != deflate || flags&reserved != 0 {
+		return GzipMember{}, 0, fmt.Errorf("%w: method or flags at %d", ErrGzipHeader, offset)
+	}
+	member := GzipMember{
+		OS:     gzipOS(p[9]),
+		Level:  GzipLevel(p[8]),
+		Text:   flags&ftext != 0,
+		Offset: offset,
+	}
+	if stamp := binary.LittleEndian.Uint32(p[4:]); stamp > 0 {
+		member.Modified = time.Unix(int64(stamp), 0).UTC()
+	}
+	pos := offset + headerLen
+	if flags&fextra != 0 {
+		size := make([]byte, 2)
+		if _, err := r.ReadAt(size, pos); err != nil {
+			return GzipMember{}, 0, fmt.Errorf("%w: extra field at %d", ErrGzipHeader, pos)
+		}
+		extra := make([]byte, binary.LittleEndian.Uint16(size))
+		if _, err := r.ReadAt(extra, pos+2); err != nil {
+			return GzipMember{}, 0, fmt.Errorf("%w: extra field at %d", ErrGzipHeader, pos)
+		}
+		member.Extra = gzipSubfields(extra)
+		pos += 2 + int64(len(extra))
+	}
+	latin := func() (string, error) {
+		b := make([]byte, maxLen)
+		n, _ := r.ReadAt(b, pos)
+		s, _, found := bytes.Cut(b[:n], []byte{0})
+		if !found {
+			return "", fmt.Errorf("%w: unterminated string at %d", ErrGzipHeader, pos)
+		}
+		pos += int64(len(s)) + 1
+		decoded, err := charmap.ISO8859_1.NewDecoder().Bytes(s)
+		return string(decoded), err
+	}
+	var err error
+	if flags&fname != 0 {
+		if member.Name, err = latin(); err != nil {
+			return GzipMember{}, 0, err
+		}
+	}
+	if flags&fcomment != 0 {
+		if member.Comment, err = latin(); err != nil {
+			return GzipMember{}, 0, err
+		}
+	}
+	if flags&fhcrc != 0 {
+		pos += 2
+	}
+	return member, pos, nil
+}
+
+// gzipSubfields returns the subfields of the extra field.
+func gzipSubfields(extra []byte) []GzipSubfield {
+	const subLen = 4
+	fields := []GzipSubfield{}
+	for len(extra) >= subLen {
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if subLen+size > len(extra) {
+			break
+		}
+		fields = append(fields, GzipSubfield{
+			ID:   string(extra[:2]),
+			Data: extra[subLen : subLen+size],
+		})
+		extra = extra[subLen+size:]
+	}
+	return fields
+}
+
+// gzipOS returns the name of the operating system byte of the member header.
+func gzipOS(id byte) string {
+	names := [...]string{
+		"FAT", "Amiga", "VMS", "Unix", "VM/CMS", "Atari TOS", "HPFS", "Macintosh",
+		"Z-System", "CP/M", "TOPS-20", "NTFS", "QDOS", "Acorn RISCOS",
+	}
+	if int(id) < len(names) {
+		return names[id]
+	}
+	const unknown = 255
+	if id == unknown {
+		return "unknown"
+	}
+	return fmt.Sprintf("unknown os %d", id)
+}
+
+// countReader counts the bytes that are read, it implements io.ByteReader so
+// the flate decompressor does not read past the end of the deflate data.
+type countReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -0,0 +1,99 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+// gzipMember returns a Gzip member of the header and content.
+func gzipMember(t *testing.T, hdr gzip.Header, content string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	be.Err(t, err, nil)
+	zw.Header = hdr
+	_, err = zw.Write([]byte(content))
+	be.Err(t, err, nil)
+	be.Err(t, zw.Close(), nil)
+	return buf.Bytes()
+}
+
+func TestGzipMembers(t *testing.T) {
+	t.Parallel()
+	t.Log("TestGzipMembers")
+	_, err := magicnumber.GzipMembers(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(gzFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	members, err := magicnumber.GzipMembers(r)
+	be.Err(t, err, nil)
+	be.Equal(t, 1, len(members))
+	m := members[0]
+	be.Equal(t, "", m.Name)
+	be.Equal(t, "", m.Comment)
+	be.Equal(t, "Unix", m.OS)
+	be.True(t, m.Modified.IsZero())
+	be.Equal(t, int64(0), m.Offset)
+	be.Equal(t, int64(3266560), m.Size)
+	be.Equal(t, uint32(0x84640c8f), m.CRC32)
+	stat, err := r.Stat()
+	be.Err(t, err, nil)
+	const headerLen, trailerLen = 10, 8
+	be.Equal(t, stat.Size()-headerLen-trailerLen, m.CompressedSize)
+
+	_, err = magicnumber.GzipMembers(strings.NewReader("not a gzip file"))
+	be.Err(t, err, magicnumber.ErrGzipHeader)
+}
+
+func TestGzipConcatenated(t *testing.T) {
+	t.Parallel()
+	t.Log("TestGzipConcatenated")
+	modified := time.Date(1996, time.June, 1, 8, 30, 0, 0, time.UTC)
+	first := gzipMember(t, gzip.Header{
+		Name:    "FILE_ID.DIZ",
+		Comment: "Défacto2",
+		Extra:   []byte{'A', 'P', 2, 0, 'h', 'i'},
+		ModTime: modified,
+		OS:      0,
+	}, strings.Repeat("the first member ", 50))
+	second := gzipMember(t, gzip.Header{OS: 3}, "the second member")
+	b := append(append([]byte{}, first...), second...)
+	// trailing padding is not a member
+	b = append(b, make([]byte, 16)...)
+	be.True(t, magicnumber.Gzip(bytes.NewReader(b)))
+	members, err := magicnumber.GzipMembers(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.Equal(t, 2, len(members))
+	m := members[0]
+	be.Equal(t, "FILE_ID.DIZ", m.Name)
+	be.Equal(t, "Défacto2", m.Comment)
+	be.Equal(t, "FAT", m.OS)
+	be.Equal(t, modified, m.Modified)
+	be.Equal(t, "maximum compression", m.Level.String())
+	be.Equal(t, 1, len(m.Extra))
+	be.Equal(t, "AP", m.Extra[0].ID)
+	be.Equal(t, "hi", string(m.Extra[0].Data))
+	be.Equal(t, int64(850), m.Size)
+	m = members[1]
+	be.Equal(t, int64(len(first)), m.Offset)
+	be.Equal(t, "Unix", m.OS)
+	be.Equal(t, int64(17), m.Size)
+
+	comments, err := magicnumber.Comments(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.Equal(t, "Défacto2", comments.Archive)
+
+	// a corrupt trailer is an error
+	b[len(first)-1] ^= 0xff
+	_, err = magicnumber.GzipMembers(bytes.NewReader(b))
+	be.Err(t, err, magicnumber.ErrGzipHeader)
+}
@@ -3,13 +3,21 @@ package magicnumber
 import (
 	"fmt"
 	"io"
+	"slices"
 )
 
 // Archive reads all the bytes from the reader and returns the file type signature if
 // the file is a known archive of files or Unknown if the file is not an archive.
 func Archive(r io.ReaderAt) (Signature, error) {
 	find := *New()
+	var zip pkClass
 	for _, archive := range Archives() {
+		if found, ok := zip.match(r, archive); ok {
+			if found {
+				return archive, nil
+			}
+			continue
+		}
 		if finder, exists := find[archive]; exists {
 			if finder(r) {
 				return archive, nil
@@ -39,17 +47,38 @@ func Archives() []Signature {
 		XZCompressArchive,
 		ZStandardArchive,
 		FreeArc,
-		NoGatePAK, // NoGatePAK must go before ARChiveSEA
+		NoGatePAK,
 		ARChiveSEA,
 		YoshiLHA,
 		ZooArchive,
 		ArchiveRobertJung,
 		MicrosoftCABinet,
+		ACEArchive,
+		HarriHirvolaHA,
+		HyperArchive,
+		SqueezeItArchive,
+		UltraCompressorII,
+		DeanCooperDWC,
+		LimitArchive,
+		CPMLibrary,
+		CPMSqueeze,
+		CPMCrunch,
+		CPMCrunchLZH,
+		StuffItArchive,
+		StuffIt5Archive,
+		CompactProArchive,
+		MacBinary,
+		AppleSingle,
+		AppleDouble,
+		BinHex,
+		AmigaLZX,
+		AmigaDMS,
+		PowerPacker,
 	}
 }
 
 // DiscImage reads all the bytes from the reader and returns the file type signature if
-// the file is a known CD disk image or Unknown if the file is not a disk image.
+// the file is a known CD or floppy disk image or Unknown if the file is not a disk image.
 func DiscImage(r io.ReaderAt) (Signature, error) {
 	find := *New()
 	for _, img := range DiscImages() {
@@ -62,13 +91,14 @@ func DiscImage(r io.ReaderAt) (Signature, error) {
 	return Unknown, nil
 }
 
-// DiscImages returns all the CD disk image file type signatures.
+// DiscImages returns all the CD and floppy disk image file type signatures.
 func DiscImages() []Signature {
 	return []Signature{
 		CDISO9660,
 		CDNero,
 		CDPowerISO,
 		CDAlcohol120,
+		AmigaADF,
 	}
 }
 
@@ -86,6 +116,21 @@ func ArchivesBBS() []Signature {
 		ZooArchive,
 		ArchiveRobertJung,
 		NoGatePAK,
+		ACEArchive,
+		HarriHirvolaHA,
+		HyperArchive,
+		SqueezeItArchive,
+		UltraCompressorII,
+		DeanCooperDWC,
+		LimitArchive,
+		CPMLibrary,
+		CPMSqueeze,
+		CPMCrunch,
+		CPMCrunchLZH,
+		StuffItArchive,
+		CompactProArchive,
+		AmigaLZX,
+		AmigaDMS,
 	}
 }
 
@@ -153,6 +198,7 @@ func Images() []Signature {
 		MicrosoftIcon,
 		RIPscrip,
 		ElectronicArtsAnim,
+		PlanarBitMap,
 	}
 }
 
@@ -181,6 +227,36 @@ func Programs() []Signature {
 	}
 }
 
+// Sound reads all the bytes from the reader and returns the file type signature if
+// the file is a known audio or music file or Unknown if the file is not a sound.
+func Sound(r io.ReaderAt) (Signature, error) {
+	find := *New()
+	for _, snd := range Sounds() {
+		if finder, exists := find[snd]; exists {
+			if finder(r) {
+				return snd, nil
+			}
+		}
+	}
+	return Unknown, nil
+}
+
+// Sounds returns all the digital audio and tracker music file type signatures.
+func Sounds() []Signature {
+	return []Signature{
+		MusicalInstrumentDigitalInterface,
+		MPEG1AudioLayer3,
+		MPEGAdvancedAudioCoding,
+		OggVorbisCodec,
+		FreeLosslessAudioCodec,
+		WaveAudioForWindows,
+		MusicExtendedModule,
+		MusicMultiTrackModule,
+		MusicImpulseTracker,
+		MusicProTracker,
+	}
+}
+
 // Text reads the first 512 bytes from the reader and returns the file type signature if
 // the file is a known plain text file or Unknown if the file is not a text file.
 func Text(r io.ReaderAt) (Signature, error) {
@@ -257,3 +333,95 @@ func Videos() []Signature {
 		RealPlayer,
 	}
 }
+
+// Category is a group of file type signatures, such as archives or images.
+type Category int
+
+const (
+	OtherCategory     Category = iota // OtherCategory are the signatures that do not belong to a group
+	ArchiveCategory                   // ArchiveCategory are the signatures returned by Archives
+	DiscImageCategory                 // DiscImageCategory are the signatures returned by DiscImages
+	ProgramCategory                   // ProgramCategory are the signatures returned by Programs
+	ImageCategory                     // ImageCategory are the signatures returned by Images
+	VideoCategory                     // VideoCategory are the signatures returned by Videos
+	SoundCategory                     // SoundCategory are the signatures returned by Sounds
+	TextCategory                      // TextCategory are the signatures returned by Texts
+	DocumentCategory                  // DocumentCategory are the signatures returned by Documents
+)
+
+func (c Category) String() string {
+	switch c {
+	case ArchiveCategory:
+		return "archive"
+	case DiscImageCategory:
+		return "disc image"
+	case ProgramCategory:
+		return "program"
+	case ImageCategory:
+		return "image"
+	case VideoCategory:
+		return "video"
+	case SoundCategory:
+		return "sound"
+	case TextCategory:
+		return "text"
+	case DocumentCategory:
+		return "document"
+	case OtherCategory:
+		return "other"
+	}
+	return ""
+}
+
+// Signatures returns the file type signatures of the category.
+// The OtherCategory returns nil.
+func (c Category) Signatures() []Signature {
+	switch c {
+	case ArchiveCategory:
+		return Archives()
+	case DiscImageCategory:
+		return DiscImages()
+	case ProgramCategory:
+		return Programs()
+	case ImageCategory:
+		return Images()
+	case VideoCategory:
+		return Videos()
+	case SoundCategory:
+		return Sounds()
+	case TextCategory:
+		return Texts()
+	case DocumentCategory:
+		return Documents()
+	case OtherCategory:
+		return nil
+	}
+	return nil
+}
+
+// Categories returns all the categories, except for OtherCategory, in the order
+// they are used by [Signature.Category]. Text is before Document, so the Unicode text
+// signatures that are listed by both Texts and Documents belong to the TextCategory.
+func Categories() []Category {
+	return []Category{
+		ArchiveCategory,
+		DiscImageCategory,
+		ProgramCategory,
+		ImageCategory,
+		VideoCategory,
+		SoundCategory,
+		TextCategory,
+		DocumentCategory,
+	}
+}
+
+// Category returns the category of the file type signature,
+// or OtherCategory if the signature does not belong to a category.
+func (sign Signature) Category() Category {
+	for _, c := range Categories() {
+		if slices.Contains(c.Signatures(), sign) {
+			return c
+		}
+	}
+	return OtherCategory
+}
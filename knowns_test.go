@@ -0,0 +1,49 @@
+package magicnumber_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestCategory(t *testing.T) {
+	t.Parallel()
+	t.Log("TestCategory")
+	be.Equal(t, magicnumber.ArchiveCategory, magicnumber.PKWAREZip.Category())
+	be.Equal(t, magicnumber.DiscImageCategory, magicnumber.CDISO9660.Category())
+	be.Equal(t, magicnumber.ProgramCategory, magicnumber.MicrosoftExecutable.Category())
+	be.Equal(t, magicnumber.ImageCategory, magicnumber.PortableNetworkGraphics.Category())
+	be.Equal(t, magicnumber.ImageCategory, magicnumber.PlanarBitMap.Category())
+	be.Equal(t, magicnumber.VideoCategory, magicnumber.MPEG4.Category())
+	be.Equal(t, magicnumber.SoundCategory, magicnumber.MusicProTracker.Category())
+	be.Equal(t, magicnumber.TextCategory, magicnumber.UTF8Text.Category())
+	be.Equal(t, magicnumber.DocumentCategory, magicnumber.PortableDocumentFormat.Category())
+	be.Equal(t, magicnumber.OtherCategory, magicnumber.Unknown.Category())
+	be.Equal(t, "disc image", magicnumber.DiscImageCategory.String())
+	be.Equal(t, "other", magicnumber.OtherCategory.String())
+	be.True(t, magicnumber.OtherCategory.Signatures() == nil)
+	for _, c := range magicnumber.Categories() {
+		be.True(t, len(c.Signatures()) > 0)
+		be.True(t, c.String() != "")
+	}
+}
+
+func TestSound(t *testing.T) {
+	t.Parallel()
+	t.Log("TestSound")
+	r, err := os.Open(mp3file(wavFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	sign, err := magicnumber.Sound(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.WaveAudioForWindows, sign)
+
+	r, err = os.Open(uncompress(pngFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	sign, err = magicnumber.Sound(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.Unknown, sign)
+}
@@ -0,0 +1,288 @@
+package magicnumber
+
+// Package file lha.go contains the functions that parse the headers of the LHA and LZH archive formats.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrLhaHeader = errors.New("lha header is invalid")
+
+// LhaEntry is a file entry of an LHA archive.
+type LhaEntry struct {
+	Path           string    // Path is the directory and filename of the entry using slash separators, decoded from CP437
+	Comment        string    // Comment is the file comment, decoded from CP437
+	Method         string    // Method is the compression method, such as -lh5-
+	OS             string    // OS is the operating system of the archiver
+	Modified       time.Time // Modified is the date and time the file was last modified
+	Offset         int64     // Offset is the position of the compressed data
+	CompressedSize int64     // CompressedSize is the size of the compressed data
+	OriginalSize   int64     // OriginalSize is the size of the file when uncompressed
+	CRC16          uint16    // CRC16 is the checksum of the uncompressed file
+	Level          int       // Level is the header level, from 0 to 3
+}
+
+// IsDir returns true if the entry is a directory.
+func (e LhaEntry) IsDir() bool {
+	return e.Method == "-lhd-"
+}
+
+// lhaMethods are the compression methods of LHA, LArc and PMarc.
+func lhaMethods() []string {
+	return []string{
+		"-lh0-", "-lh1-", "-lh2-", "-lh3-", "-lh4-", "-lh5-", "-lh6-", "-lh7-", "-lhd-", "-lhx-",
+		"-lzs-", "-lz4-", "-lz5-",
+		"-pm0-", "-pm1-", "-pm2-", "-pms-",
+	}
+}
+
+// LhaHeaders parses the headers of an LHA archive and returns the entries in the stored order.
+// Header levels 0, 1, 2 and 3 are supported, including the extended headers for
+// the filename, directory name, comment, 64-bit sizes and Unix timestamp.
+// LHA archives have no archive header and the first entry starts at the beginning of the file.
+func LhaHeaders(r io.ReaderAt) ([]LhaEntry, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	entries := []LhaEntry{}
+	offset := int64(0)
+	for range maxBlocks {
+		p := make([]byte, 1)
+		if n, _ := r.ReadAt(p, offset); n == 0 || p[0] == 0 {
+			// the archive ends with a zero byte or at the end of the file
+			if len(entries) == 0 {
+				return nil, fmt.Errorf("%w: no entries", ErrLhaHeader)
+			}
+			return entries, nil
+		}
+		entry, next, err := lhaHeader(r, offset)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+		offset = next
+	}
+	return entries, nil
+}
+
+// lhaHeader parses the header at the offset and returns the entry and the offset of the next header.
+func lhaHeader(r io.ReaderAt, offset int64) (LhaEntry, int64, error) {
+	const baseLen = 22
+	p := make([]byte, baseLen)
+	if n, _ := r.ReadAt(p, offset); n < baseLen {
+		return LhaEntry{}, 0, fmt.Errorf("%w: short header at %d", ErrLhaHeader, offset)
+	}
+	le := binary.LittleEndian
+	entry := LhaEntry{
+		Method:         string(p[2:7]),
+		CompressedSize: int64(le.Uint32(p[7:])),
+		OriginalSize:   int64(le.Uint32(p[11:])),
+		Level:          int(p[20]),
+	}
+	if !slices.Contains(lhaMethods(), entry.Method) {
+		return LhaEntry{}, 0, fmt.Errorf("%w: method %q at %d", ErrLhaHeader, entry.Method, offset)
+	}
+	stamp := le.Uint32(p[15:])
+	var ext lhaExtended
+	var err error
+	switch entry.Level {
+	case 0, 1:
+		entry.Modified = DosTime(uint16(stamp>>16), uint16(stamp))
+		ext, err = lhaLevel01(r, offset, &entry)
+	case 2, 3:
+		entry.Modified = time.Unix(int64(stamp), 0).UTC()
+		ext, err = lhaLevel23(r, offset, &entry)
+	default:
+		return LhaEntry{}, 0, fmt.Errorf("%w: level %d at %d", ErrLhaHeader, entry.Level, offset)
+	}
+	if err != nil {
+		return LhaEntry{}, 0, err
+	}
+	ext.apply(&entry)
+	next := entry.Offset + entry.CompressedSize
+	if next <= offset {
+		return LhaEntry{}, 0, fmt.Errorf("%w: entry at %d links backwards", ErrLhaHeader, offset)
+	}
+	return entry, next, nil
+}
+
+// lhaLevel01 reads the level 0 and level 1 headers. The filename of the base header may
+// contain an Amiga LhA comment after a NUL. Level 1 extended headers follow the base header
+// and are included in the packed size.
+func lhaLevel01(r io.ReaderAt, offset int64, entry *LhaEntry) (lhaExtended, error) {
+	var ext lhaExtended
+	size := make([]byte, 1)
+	if _, err := r.ReadAt(size, offset); err != nil {
+		return ext, fmt.Errorf("%w: header at %d", ErrLhaHeader, offset)
+	}
+	baseLen := int64(size[0]) + 2
+	head := make([]byte, baseLen)
+	if _, err := r.ReadAt(head, offset); err != nil {
+		return ext, fmt.Errorf("%w: header at %d", ErrLhaHeader, offset)
+	}
+	const nameLen, nameStart, crcLen = 21, 22, 2
+	end := nameStart + int(head[nameLen])
+	if end+crcLen > len(head) {
+		return ext, fmt.Errorf("%w: filename length at %d", ErrLhaHeader, offset)
+	}
+	name, comment, _ := bytes.Cut(head[nameStart:end], []byte{0})
+	ext.name = name
+	ext.comment = comment
+	entry.CRC16 = binary.LittleEndian.Uint16(head[end:])
+	entry.Offset = offset + baseLen
+	if entry.Level == 0 {
+		entry.OS = lhaOS(0)
+		return ext, nil
+	}
+	// level 1 ends with the os id and the size of the first extended header
+	if end+crcLen+3 > len(head) {
+		return ext, fmt.Errorf("%w: level 1 header at %d", ErrLhaHeader, offset)
+	}
+	entry.OS = lhaOS(head[end+crcLen])
+	const sizeLen = 2
+	extSize := int64(binary.LittleEndian.Uint16(head[len(head)-sizeLen:]))
+	for range maxBlocks {
+		if extSize == 0 {
+			break
+		}
+		if extSize < 1+sizeLen {
+			return ext, fmt.Errorf("%w: extended header at %d", ErrLhaHeader, entry.Offset)
+		}
+		field := make([]byte, extSize)
+		if _, err := r.ReadAt(field, entry.Offset); err != nil {
+			return ext, fmt.Errorf("%w: extended header at %d", ErrLhaHeader, entry.Offset)
+		}
+		ext.parse(field[0], field[1:extSize-sizeLen])
+		entry.Offset += extSize
+		entry.CompressedSize -= extSize
+		extSize = int64(binary.LittleEndian.Uint16(field[extSize-sizeLen:]))
+	}
+	return ext, nil
+}
+
+// lhaLevel23 reads the level 2 and level 3 headers, where the header size includes the
+// extended headers. Level 2 uses 16-bit sizes and level 3 uses 32-bit sizes.
+func lhaLevel23(r io.ReaderAt, offset int64, entry *LhaEntry) (lhaExtended, error) {
+	var ext lhaExtended
+	const maxHeader = 1 << 20
+	p := make([]byte, 32)
+	if n, _ := r.ReadAt(p, offset); n < 26 {
+		return ext, fmt.Errorf("%w: header at %d", ErrLhaHeader, offset)
+	}
+	le := binary.LittleEndian
+	headerLen, sizeLen, extStart := int64(le.Uint16(p)), 2, 24
+	if entry.Level == 3 {
+		headerLen, sizeLen, extStart = int64(le.Uint32(p[24:])), 4, 28
+	}
+	if headerLen < int64(extStart+sizeLen) || headerLen > maxHeader {
+		return ext, fmt.Errorf("%w: header size %d at %d", ErrLhaHeader, headerLen, offset)
+	}
+	head := make([]byte, headerLen)
+	if _, err := r.ReadAt(head, offset); err != nil {
+		return ext, fmt.Errorf("%w: header at %d", ErrLhaHeader, offset)
+	}
+	entry.CRC16 = le.Uint16(head[21:])
+	entry.OS = lhaOS(head[23])
+	entry.Offset = offset + headerLen
+	size := func(b []byte) int {
+		if sizeLen == 4 {
+			return int(le.Uint32(b))
+		}
+		return int(le.Uint16(b))
+	}
+	pos := extStart + sizeLen
+	extSize := size(head[extStart:])
+	for range maxBlocks {
+		if extSize == 0 {
+			break
+		}
+		if extSize < 1+sizeLen || pos+extSize > len(head) {
+			return ext, fmt.Errorf("%w: extended header at %d", ErrLhaHeader, offset+int64(pos))
+		}
+		field := head[pos : pos+extSize]
+		ext.parse(field[0], field[1:extSize-sizeLen])
+		extSize = size(field[extSize-sizeLen:])
+		pos += len(field)
+	}
+	return ext, nil
+}
+
+// lhaExtended are the values of the extended headers.
+type lhaExtended struct {
+	name, dir, comment []byte
+	unixTime           uint32
+	compressed         int64
+	original           int64
+}
+
+// parse reads the data of the extended header type.
+func (ext *lhaExtended) parse(typ byte, data []byte) {
+	const (
+		filename  = 0x01
+		directory = 0x02
+		comment   = 0x3f
+		sizes     = 0x42
+		unixTime  = 0x54
+	)
+	switch typ {
+	case filename:
+		ext.name = data
+	case directory:
+		ext.dir = data
+	case comment:
+		ext.comment = data
+	case sizes:
+		if len(data) >= 16 {
+			ext.compressed = int64(binary.LittleEndian.Uint64(data))
+			ext.original = int64(binary.LittleEndian.Uint64(data[8:]))
+		}
+	case unixTime:
+		if len(data) >= 4 {
+			ext.unixTime = binary.LittleEndian.Uint32(data)
+		}
+	}
+}
+
+// apply sets the path, comment, sizes and time of the entry.
+func (ext lhaExtended) apply(entry *LhaEntry) {
+	// directory names are separated by 0xff and older MS-DOS archives use backslashes
+	dir := strings.ReplaceAll(DecodeCP437(bytes.ReplaceAll(ext.dir, []byte{0xff}, []byte{'/'})), "\\", "/")
+	name := strings.ReplaceAll(DecodeCP437(ext.name), "\\", "/")
+	switch {
+	case dir == "":
+		entry.Path = name
+	case strings.HasSuffix(dir, "/"):
+		entry.Path = dir + name
+	default:
+		entry.Path = dir + "/" + name
+	}
+	entry.Comment = DecodeCP437(ext.comment)
+	if ext.compressed > 0 || ext.original > 0 {
+		entry.CompressedSize = ext.compressed
+		entry.OriginalSize = ext.original
+	}
+	if ext.unixTime > 0 {
+		entry.Modified = time.Unix(int64(ext.unixTime), 0).UTC()
+	}
+}
+
+// lhaOS returns the name of the operating system id.
+func lhaOS(id byte) string {
+	names := map[byte]string{
+		0: "generic", 'M': "MS-DOS", '2': "OS/2", '9': "OS-9", 'K': "OS/68K", '3': "OS/386",
+		'H': "Human68K", 'U': "Unix", 'C': "CP/M", 'F': "FLEX", 'm': "Mac OS", 'R': "Runser",
+		'T': "TownsOS", 'X': "XOSK", 'A': "Amiga", 'a': "Atari ST", 'w': "Windows 95",
+		'W': "Windows NT", 'J': "Java",
+	}
+	if s, ok := names[id]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown os %q", id)
+}
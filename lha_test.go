@@ -0,0 +1,143 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestLhaHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestLhaHeaders")
+	_, err := magicnumber.LhaHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(lhaFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	entries, err := magicnumber.LhaHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, 15, len(entries))
+	ans := entries[0]
+	be.Equal(t, "TEST.ANS", ans.Path)
+	be.Equal(t, "-lh0-", ans.Method)
+	be.Equal(t, 2, ans.Level)
+	be.Equal(t, "Unix", ans.OS)
+	be.Equal(t, int64(68), ans.CompressedSize)
+	be.Equal(t, int64(68), ans.OriginalSize)
+	be.Equal(t, time.Date(2024, time.February, 1, 12, 54, 59, 0, time.UTC), ans.Modified)
+	p := make([]byte, ans.CompressedSize)
+	_, err = r.ReadAt(p, ans.Offset)
+	be.Err(t, err, nil)
+	be.True(t, bytes.HasPrefix(p, []byte("\x1b[1mThis")))
+	bmp := entries[2]
+	be.Equal(t, "TEST.BMP", bmp.Path)
+	be.Equal(t, "-lh5-", bmp.Method)
+	be.Equal(t, int64(2340), bmp.CompressedSize)
+	be.Equal(t, int64(750054), bmp.OriginalSize)
+	be.True(t, !bmp.IsDir())
+
+	_, err = magicnumber.LhaHeaders(bytes.NewReader([]byte("not an lha archive")))
+	be.Err(t, err, magicnumber.ErrLhaHeader)
+}
+
+// lhaLevel01 returns a level 0 or level 1 header, followed by the extended headers and data.
+func lhaLevel01(level byte, method, name string, os byte, exts [][]byte, data string) []byte {
+	head := make([]byte, 22)
+	copy(head[2:], method)
+	binary.LittleEndian.PutUint32(head[11:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(head[15:], 0x41337000) // 2012-09-19 14:00:00
+	head[20] = level
+	head[21] = byte(len(name))
+	head = append(head, name...)
+	head = append(head, 0, 0) // crc16
+	packed := len(data)
+	if level == 1 {
+		head = append(head, os)
+		size := 0
+		if len(exts) > 0 {
+			size = len(exts[0]) + 2
+		}
+		head = binary.LittleEndian.AppendUint16(head, uint16(size))
+	}
+	head[0] = byte(len(head) - 2)
+	for i, ext := range exts {
+		next := 0
+		if i+1 < len(exts) {
+			next = len(exts[i+1]) + 2
+		}
+		head = append(head, ext...)
+		head = binary.LittleEndian.AppendUint16(head, uint16(next))
+		packed += len(ext) + 2
+	}
+	binary.LittleEndian.PutUint32(head[7:], uint32(packed))
+	return append(head, data...)
+}
+
+func TestLhaLevels(t *testing.T) {
+	t.Parallel()
+	t.Log("TestLhaLevels")
+	lha := lhaLevel01(0, "-lz4-", "DOCS\\README.TXT", 0, nil, "hello")
+	lha = append(lha, lhaLevel01(1, "-lh5-", "FILE_ID.DIZ", 'M',
+		[][]byte{append([]byte{0x02}, "GAMES\xffDOOM"...), {0x54, 0x00, 0x5e, 0xd0, 0xb2}}, "world")...)
+	// a level 3 header with 32-bit extended header sizes
+	l3 := make([]byte, 32)
+	copy(l3[2:], "-pm2-")
+	binary.LittleEndian.PutUint16(l3, 4)
+	binary.LittleEndian.PutUint32(l3[7:], 3)
+	binary.LittleEndian.PutUint32(l3[11:], 3)
+	binary.LittleEndian.PutUint32(l3[15:], 1000000000)
+	l3[20], l3[23] = 3, 'A'
+	name := append([]byte{0x01}, "amiga.mod"...)
+	binary.LittleEndian.PutUint32(l3[28:], uint32(len(name)+4))
+	l3 = append(l3, name...)
+	l3 = append(l3, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(l3[24:], uint32(len(l3)))
+	l3 = append(l3, "mod"...)
+	lha = append(lha, l3...)
+	lha = append(lha, 0)
+
+	be.True(t, magicnumber.LzhLha(bytes.NewReader(lha)))
+	entries, err := magicnumber.LhaHeaders(bytes.NewReader(lha))
+	be.Err(t, err, nil)
+	be.Equal(t, 3, len(entries))
+
+	be.Equal(t, "DOCS/README.TXT", entries[0].Path)
+	be.Equal(t, "-lz4-", entries[0].Method)
+	be.Equal(t, time.Date(2012, time.September, 19, 14, 0, 0, 0, time.UTC), entries[0].Modified)
+	be.Equal(t, int64(5), entries[0].CompressedSize)
+
+	be.Equal(t, "GAMES/DOOM/FILE_ID.DIZ", entries[1].Path)
+	be.Equal(t, "MS-DOS", entries[1].OS)
+	be.Equal(t, 1, entries[1].Level)
+	be.Equal(t, int64(5), entries[1].CompressedSize)
+	be.Equal(t, time.Unix(0xb2d05e00, 0).UTC(), entries[1].Modified)
+	p := make([]byte, 5)
+	_, err = bytes.NewReader(lha).ReadAt(p, entries[1].Offset)
+	be.Err(t, err, nil)
+	be.Equal(t, "world", string(p))
+
+	be.Equal(t, "amiga.mod", entries[2].Path)
+	be.Equal(t, "-pm2-", entries[2].Method)
+	be.Equal(t, "Amiga", entries[2].OS)
+	be.Equal(t, 3, entries[2].Level)
+	be.Equal(t, time.Unix(1000000000, 0).UTC(), entries[2].Modified)
+
+	be.True(t, !magicnumber.LzhLha(bytes.NewReader(lhaLevel01(0, "-xx0-", "A", 0, nil, "a"))))
+
+	// a 64-bit compressed size that links back to the start of the archive
+	sizes := make([]byte, 17)
+	sizes[0] = 0x42
+	back := lhaLevel01(1, "-lh5-", "LOOP.TXT", 'M', [][]byte{sizes}, "")
+	binary.LittleEndian.PutUint64(sizes[1:], uint64(-int64(len(back))))
+	binary.LittleEndian.PutUint64(sizes[9:], 1)
+	back = lhaLevel01(1, "-lh5-", "LOOP.TXT", 'M', [][]byte{sizes}, "")
+	entries, err = magicnumber.LhaHeaders(bytes.NewReader(back))
+	be.Err(t, err, magicnumber.ErrLhaHeader)
+	be.Equal(t, 0, len(entries))
+}
@@ -0,0 +1,429 @@
+package magicnumber
+
+// Package file lzma.go contains a minimal LZMA and LZMA2 decoder by Igor Pavlov,
+// which is used to read the compressed headers of 7-Zip archives.
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errLZMA = errors.New("lzma data is corrupt")
+
+const (
+	lzmaStates     = 12
+	lzmaPosBitsMax = 4
+	lzmaProbInit   = 1024
+	lzmaEndPos     = 14  // the first position slot that uses the align bits
+	lzmaFullDist   = 128 // the number of distances that are decoded with the position bit trees
+	lzmaMatchMin   = 2
+	lzmaLiterals   = 0x300
+)
+
+// lzmaRange is the range decoder of an LZMA stream.
+type lzmaRange struct {
+	in   []byte
+	pos  int
+	rng  uint32
+	code uint32
+	bad  bool
+}
+
+// init starts the range decoder with the first five bytes of the compressed data.
+func (rc *lzmaRange) init(in []byte) bool {
+	const initLen = 5
+	if len(in) < initLen || in[0] != 0 {
+		return false
+	}
+	rc.in, rc.pos = in, initLen
+	rc.rng, rc.code = 0xffffffff, binary.BigEndian.Uint32(in[1:])
+	rc.bad = false
+	return rc.code != rc.rng
+}
+
+func (rc *lzmaRange) next() byte {
+	if rc.pos >= len(rc.in) {
+		rc.bad = true
+		return 0
+	}
+	b := rc.in[rc.pos]
+	rc.pos++
+	return b
+}
+
+func (rc *lzmaRange) normalize() {
+	const top = 1 << 24
+	if rc.rng < top {
+		rc.rng <<= 8
+		rc.code = rc.code<<8 | uint32(rc.next())
+	}
+}
+
+// bit decodes a bit using the probability, which is then updated.
+func (rc *lzmaRange) bit(prob *uint16) uint32 {
+	const total, moveBits = 1 << 11, 5
+	bound := (rc.rng >> 11) * uint32(*prob)
+	var b uint32
+	if rc.code < bound {
+		rc.rng = bound
+		*prob += (total - *prob) >> moveBits
+	} else {
+		rc.rng -= bound
+		rc.code -= bound
+		*prob -= *prob >> moveBits
+		b = 1
+	}
+	rc.normalize()
+	return b
+}
+
+// direct decodes the bits that use a fixed probability of one half.
+func (rc *lzmaRange) direct(count int) uint32 {
+	var res uint32
+	for range count {
+		rc.rng >>= 1
+		rc.code -= rc.rng
+		t := 0 - (rc.code >> 31)
+		rc.code += rc.rng & t
+		rc.normalize()
+		res = res<<1 + t + 1
+	}
+	return res
+}
+
+// tree decodes a symbol of the bit count from the bit tree of probabilities.
+func (rc *lzmaRange) tree(probs []uint16, count int) uint32 {
+	m := uint32(1)
+	for range count {
+		m = m<<1 + rc.bit(&probs[m])
+	}
+	return m - 1<<count
+}
+
+// reverse decodes a symbol of the bit count from the bit tree of probabilities, low bit first.
+func (rc *lzmaRange) reverse(probs []uint16, count int) uint32 {
+	m, sym := uint32(1), uint32(0)
+	for i := range count {
+		b := rc.bit(&probs[m])
+		m = m<<1 + b
+		sym |= b << i
+	}
+	return sym
+}
+
+// lzmaLength is the match length decoder.
+type lzmaLength struct {
+	choice  uint16
+	choice2 uint16
+	low     [1 << lzmaPosBitsMax][1 << 3]uint16
+	mid     [1 << lzmaPosBitsMax][1 << 3]uint16
+	high    [1 << 8]uint16
+}
+
+func (l *lzmaLength) reset() {
+	l.choice, l.choice2 = lzmaProbInit, lzmaProbInit
+	for i := range l.low {
+		fill(l.low[i][:])
+		fill(l.mid[i][:])
+	}
+	fill(l.high[:])
+}
+
+func (l *lzmaLength) decode(rc *lzmaRange, posState uint32) uint32 {
+	if rc.bit(&l.choice) == 0 {
+		return rc.tree(l.low[posState][:], 3)
+	}
+	if rc.bit(&l.choice2) == 0 {
+		return 8 + rc.tree(l.mid[posState][:], 3)
+	}
+	return 16 + rc.tree(l.high[:], 8)
+}
+
+// lzmaDecoder decodes LZMA data to the out buffer, which is also the dictionary.
+type lzmaDecoder struct {
+	out        []byte
+	literals   []uint16
+	lc, lp, pb uint
+	state      uint32
+	reps       [4]uint32
+	isMatch    [lzmaStates << lzmaPosBitsMax]uint16
+	isRep      [lzmaStates]uint16
+	isRepG0    [lzmaStates]uint16
+	isRepG1    [lzmaStates]uint16
+	isRepG2    [lzmaStates]uint16
+	isRep0Long [lzmaStates << lzmaPosBitsMax]uint16
+	posSlot    [4][1 << 6]uint16
+	posProbs   [1 + lzmaFullDist - lzmaEndPos]uint16
+	align      [1 << 4]uint16
+	length     lzmaLength
+	repLength  lzmaLength
+}
+
+// props sets the literal context, literal position and position bits from the properties byte.
+func (d *lzmaDecoder) props(b byte) bool {
+	const maxProps = 9 * 5 * 5
+	if b >= maxProps {
+		return false
+	}
+	d.lc, d.lp, d.pb = uint(b%9), uint(b/9%5), uint(b/45)
+	return d.pb <= lzmaPosBitsMax
+}
+
+// reset sets the probabilities and the state to the initial values.
+func (d *lzmaDecoder) reset() {
+	d.literals = make([]uint16, lzmaLiterals<<(d.lc+d.lp))
+	fill(d.literals)
+	for _, p := range [][]uint16{
+		d.isMatch[:], d.isRep[:], d.isRepG0[:], d.isRepG1[:], d.isRepG2[:],
+		d.isRep0Long[:], d.posProbs[:], d.align[:],
+	} {
+		fill(p)
+	}
+	for i := range d.posSlot {
+		fill(d.posSlot[i][:])
+	}
+	d.length.reset()
+	d.repLength.reset()
+	d.state = 0
+	d.reps = [4]uint32{}
+}
+
+// decode appends size bytes to the out buffer from the compressed data, or less if an end marker is found.
+func (d *lzmaDecoder) decode(rc *lzmaRange, size int) error {
+	end := len(d.out) + size
+	for len(d.out) < end {
+		if rc.bad {
+			return errLZMA
+		}
+		pos := uint32(len(d.out))
+		posState := pos & (1<<d.pb - 1)
+		if rc.bit(&d.isMatch[d.state<<lzmaPosBitsMax+posState]) == 0 {
+			d.literal(rc, pos)
+			continue
+		}
+		var length uint32
+		switch {
+		case rc.bit(&d.isRep[d.state]) != 0:
+			if pos == 0 {
+				return errLZMA
+			}
+			if rc.bit(&d.isRepG0[d.state]) == 0 {
+				if rc.bit(&d.isRep0Long[d.state<<lzmaPosBitsMax+posState]) == 0 {
+					if d.reps[0] >= pos {
+						return errLZMA
+					}
+					d.state = shortRepState(d.state)
+					d.out = append(d.out, d.out[pos-d.reps[0]-1])
+					continue
+				}
+			} else {
+				var dist uint32
+				if rc.bit(&d.isRepG1[d.state]) == 0 {
+					dist = d.reps[1]
+				} else {
+					if rc.bit(&d.isRepG2[d.state]) == 0 {
+						dist = d.reps[2]
+					} else {
+						dist = d.reps[3]
+						d.reps[3] = d.reps[2]
+					}
+					d.reps[2] = d.reps[1]
+				}
+				d.reps[1] = d.reps[0]
+				d.reps[0] = dist
+			}
+			length = d.repLength.decode(rc, posState)
+			d.state = repState(d.state)
+		default:
+			d.reps[3], d.reps[2], d.reps[1] = d.reps[2], d.reps[1], d.reps[0]
+			length = d.length.decode(rc, posState)
+			d.state = matchState(d.state)
+			d.reps[0] = d.distance(rc, length)
+			if d.reps[0] == 0xffffffff {
+				// the end of stream marker
+				return nil
+			}
+		}
+		length += lzmaMatchMin
+		if d.reps[0] >= pos {
+			return errLZMA
+		}
+		from := len(d.out) - int(d.reps[0]) - 1
+		for i := 0; i < int(length) && len(d.out) < end; i++ {
+			d.out = append(d.out, d.out[from+i])
+		}
+	}
+	return nil
+}
+
+// literal decodes a literal byte, which uses the byte at the last match distance after a match.
+func (d *lzmaDecoder) literal(rc *lzmaRange, pos uint32) {
+	var prev uint32
+	if pos > 0 {
+		prev = uint32(d.out[pos-1])
+	}
+	litState := (pos&(1<<d.lp-1))<<d.lc + prev>>(8-d.lc)
+	probs := d.literals[lzmaLiterals*litState:]
+	sym := uint32(1)
+	const matchStates = 7
+	if d.state >= matchStates && d.reps[0] < pos {
+		match := uint32(d.out[pos-d.reps[0]-1])
+		for sym < 0x100 {
+			matchBit := match >> 7 & 1
+			match <<= 1
+			b := rc.bit(&probs[(1+matchBit)<<8+sym])
+			sym = sym<<1 | b
+			if matchBit != b {
+				break
+			}
+		}
+	}
+	for sym < 0x100 {
+		sym = sym<<1 | rc.bit(&probs[sym])
+	}
+	d.out = append(d.out, byte(sym))
+	switch {
+	case d.state < 4:
+		d.state = 0
+	case d.state < 10:
+		d.state -= 3
+	default:
+		d.state -= 6
+	}
+}
+
+// distance decodes the distance of a match with the length.
+func (d *lzmaDecoder) distance(rc *lzmaRange, length uint32) uint32 {
+	lenState := min(length, 3)
+	slot := rc.tree(d.posSlot[lenState][:], 6)
+	if slot < 4 {
+		return slot
+	}
+	bits := int(slot>>1) - 1
+	dist := (2 | slot&1) << bits
+	if slot < lzmaEndPos {
+		return dist + rc.reverse(d.posProbs[dist-slot:], bits)
+	}
+	const alignBits = 4
+	dist += rc.direct(bits-alignBits) << alignBits
+	return dist + rc.reverse(d.align[:], alignBits)
+}
+
+func matchState(state uint32) uint32 {
+	if state < 7 {
+		return 7
+	}
+	return 10
+}
+
+func repState(state uint32) uint32 {
+	if state < 7 {
+		return 8
+	}
+	return 11
+}
+
+func shortRepState(state uint32) uint32 {
+	if state < 7 {
+		return 9
+	}
+	return 11
+}
+
+func fill(probs []uint16) {
+	for i := range probs {
+		probs[i] = lzmaProbInit
+	}
+}
+
+// lzmaDecode decompresses the LZMA data of a 7-Zip coder, where props are
+// the five bytes of coder properties and size is the uncompressed size.
+func lzmaDecode(props, in []byte, size int) ([]byte, error) {
+	const propsLen = 5
+	var d lzmaDecoder
+	if len(props) < propsLen || !d.props(props[0]) {
+		return nil, errLZMA
+	}
+	d.out = make([]byte, 0, size)
+	d.reset()
+	var rc lzmaRange
+	if !rc.init(in) {
+		return nil, errLZMA
+	}
+	if err := d.decode(&rc, size); err != nil {
+		return nil, err
+	}
+	if len(d.out) != size {
+		return nil, errLZMA
+	}
+	return d.out, nil
+}
+
+// lzma2Decode decompresses the chunks of LZMA2 data of a 7-Zip coder, where size is the uncompressed size.
+func lzma2Decode(in []byte, size int) ([]byte, error) {
+	var d lzmaDecoder
+	d.out = make([]byte, 0, size)
+	ready := false
+	for pos := 0; pos < len(in); {
+		control := in[pos]
+		pos++
+		const (
+			endChunk  = 0x00
+			copyReset = 0x01
+			copyChunk = 0x02
+			lzmaChunk = 0x80
+		)
+		switch {
+		case control == endChunk:
+			if len(d.out) != size {
+				return nil, errLZMA
+			}
+			return d.out, nil
+		case control == copyReset, control == copyChunk:
+			if pos+2 > len(in) {
+				return nil, errLZMA
+			}
+			n := int(binary.BigEndian.Uint16(in[pos:])) + 1
+			pos += 2
+			if pos+n > len(in) || len(d.out)+n > size {
+				return nil, errLZMA
+			}
+			d.out = append(d.out, in[pos:pos+n]...)
+			pos += n
+		case control >= lzmaChunk:
+			const headLen = 4
+			if pos+headLen > len(in) {
+				return nil, errLZMA
+			}
+			unpacked := int(control&0x1f)<<16 + int(binary.BigEndian.Uint16(in[pos:])) + 1
+			packed := int(binary.BigEndian.Uint16(in[pos+2:])) + 1
+			pos += headLen
+			const stateReset, propsReset = 1, 2
+			reset := control >> 5 & 0x3
+			if reset >= propsReset {
+				if pos >= len(in) || !d.props(in[pos]) || d.lc+d.lp > 4 {
+					return nil, errLZMA
+				}
+				pos++
+				ready = true
+			}
+			if !ready || pos+packed > len(in) || len(d.out)+unpacked > size {
+				return nil, errLZMA
+			}
+			if reset >= stateReset {
+				d.reset()
+			}
+			var rc lzmaRange
+			if !rc.init(in[pos : pos+packed]) {
+				return nil, errLZMA
+			}
+			if err := d.decode(&rc, unpacked); err != nil {
+				return nil, err
+			}
+			pos += packed
+		default:
+			return nil, errLZMA
+		}
+	}
+	return nil, errLZMA
+}
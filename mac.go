@@ -0,0 +1,238 @@
+package magicnumber
+
+// Package file mac.go contains the functions that parse the headers of the MacBinary,
+// AppleSingle and AppleDouble formats, which encode the forks and the Finder information
+// of a classic Macintosh file for use on other file systems.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var ErrMacHeader = errors.New("macintosh file header is invalid")
+
+// MacFile is the original Macintosh file that is encoded by a MacBinary, AppleSingle or AppleDouble file.
+type MacFile struct {
+	Signature      Signature // Signature is the MacBinary, AppleSingle or AppleDouble format
+	Version        int       // Version is 1, 2 or 3 for MacBinary, or 1 or 2 for AppleSingle and AppleDouble
+	Name           string    // Name is the original Macintosh filename, decoded from Mac OS Roman
+	Type           string    // Type is the four letter file type code, such as TEXT or APPL
+	Creator        string    // Creator is the four letter creator code of the application, such as ttxt
+	Created        time.Time // Created is the creation date, or zero when it is not stored
+	Modified       time.Time // Modified is the modification date, or zero when it is not stored
+	DataOffset     int64     // DataOffset is the position of the data fork
+	DataLength     int64     // DataLength is the size of the data fork
+	ResourceOffset int64     // ResourceOffset is the position of the resource fork
+	ResourceLength int64     // ResourceLength is the size of the resource fork
+}
+
+// DataFork returns a reader of the data fork, which can be passed to [Find].
+func (m MacFile) DataFork(r io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(r, m.DataOffset, m.DataLength)
+}
+
+// ResourceFork returns a reader of the resource fork.
+func (m MacFile) ResourceFork(r io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(r, m.ResourceOffset, m.ResourceLength)
+}
+
+const (
+	macBinaryLen = 128        // macBinaryLen is the size of the MacBinary header and the block size of the forks
+	appleSingle  = 0x00051600 // appleSingle is the magic of the AppleSingle format
+	appleDouble  = 0x00051607 // appleDouble is the magic of the AppleDouble format
+)
+
+// MacHeaders parses the header of a MacBinary, AppleSingle or AppleDouble file and returns the
+// original Macintosh filename, the type and creator codes and the location of the forks.
+func MacHeaders(r io.ReaderAt) (MacFile, error) {
+	if r == nil {
+		return MacFile{}, ErrNilReader
+	}
+	switch {
+	case AppleSingleFile(r):
+		return appleHeaders(r, AppleSingle)
+	case AppleDoubleFile(r):
+		return appleHeaders(r, AppleDouble)
+	case MacBin(r):
+		p := make([]byte, macBinaryLen)
+		if _, err := r.ReadAt(p, 0); err != nil {
+			return MacFile{}, fmt.Errorf("%w: macbinary header", ErrMacHeader)
+		}
+		be := binary.BigEndian
+		mac := MacFile{
+			Signature:      MacBinary,
+			Version:        macBinary(p, Length(r)),
+			Name:           macRoman(p[2 : 2+p[1]]),
+			Type:           macRoman(p[65:69]),
+			Creator:        macRoman(p[69:73]),
+			Created:        macTime(be.Uint32(p[91:])),
+			Modified:       macTime(be.Uint32(p[95:])),
+			DataOffset:     macBinaryLen,
+			DataLength:     int64(be.Uint32(p[83:])),
+			ResourceLength: int64(be.Uint32(p[87:])),
+		}
+		// the forks are padded to a multiple of 128 bytes
+		mac.ResourceOffset = macBinaryLen + (mac.DataLength+macBinaryLen-1)/macBinaryLen*macBinaryLen
+		return mac, nil
+	}
+	return MacFile{}, fmt.Errorf("%w: no signature", ErrMacHeader)
+}
+
+// appleHeaders reads the entries of the AppleSingle or AppleDouble header.
+func appleHeaders(r io.ReaderAt, sign Signature) (MacFile, error) {
+	const (
+		headerLen = 26
+		entryLen  = 12
+		dataFork  = 1
+		resFork   = 2
+		realName  = 3
+		fileInfo  = 7
+		fileDates = 8
+		finder    = 9
+		maxName   = 255
+		unknown   = 0x80000000
+	)
+	be := binary.BigEndian
+	p := make([]byte, headerLen)
+	if _, err := r.ReadAt(p, 0); err != nil {
+		return MacFile{}, fmt.Errorf("%w: apple header", ErrMacHeader)
+	}
+	mac := MacFile{
+		Signature: sign,
+		Version:   int(be.Uint32(p[4:]) >> 16),
+	}
+	count := int(be.Uint16(p[24:]))
+	entries := make([]byte, count*entryLen)
+	if _, err := r.ReadAt(entries, headerLen); err != nil {
+		return MacFile{}, fmt.Errorf("%w: apple entries at %d", ErrMacHeader, headerLen)
+	}
+	for e := range count {
+		entry := entries[e*entryLen:]
+		id := be.Uint32(entry)
+		offset, length := int64(be.Uint32(entry[4:])), int64(be.Uint32(entry[8:]))
+		switch id {
+		case dataFork:
+			mac.DataOffset, mac.DataLength = offset, length
+			continue
+		case resFork:
+			mac.ResourceOffset, mac.ResourceLength = offset, length
+			continue
+		case realName, fileInfo, fileDates, finder:
+		default:
+			continue
+		}
+		b := make([]byte, min(length, maxName))
+		if _, err := r.ReadAt(b, offset); err != nil {
+			return MacFile{}, fmt.Errorf("%w: apple entry %d at %d", ErrMacHeader, id, offset)
+		}
+		const datesLen, finderLen = 8, 8
+		switch {
+		case id == realName:
+			mac.Name = macRoman(b)
+		case id == fileInfo && len(b) >= datesLen && mac.Version == 1:
+			// version 1 uses the Macintosh file info with the dates in seconds since 1904
+			mac.Created, mac.Modified = macTime(be.Uint32(b)), macTime(be.Uint32(b[4:]))
+		case id == fileDates && len(b) >= datesLen:
+			// the dates are signed seconds since the year 2000
+			y2k := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+			if created := be.Uint32(b); created != unknown {
+				mac.Created = y2k.Add(time.Duration(int32(created)) * time.Second)
+			}
+			if modified := be.Uint32(b[4:]); modified != unknown {
+				mac.Modified = y2k.Add(time.Duration(int32(modified)) * time.Second)
+			}
+		case id == finder && len(b) >= finderLen:
+			mac.Type, mac.Creator = macRoman(b[:4]), macRoman(b[4:8])
+		}
+	}
+	return mac, nil
+}
+
+// macBinary returns the MacBinary version of the header, or 0 if it is not a valid header.
+// The length is the size of the file, which is used to check the fork lengths when it is known.
+func macBinary(p []byte, length int64) int {
+	if len(p) < macBinaryLen || p[0] != 0 || p[74] != 0 || p[82] != 0 {
+		return 0
+	}
+	const maxName = 63
+	if p[1] == 0 || p[1] > maxName || !macName(p[2:2+p[1]]) {
+		return 0
+	}
+	be := binary.BigEndian
+	data, res := int64(be.Uint32(p[83:])), int64(be.Uint32(p[87:]))
+	const maxFork = 0x7fffffff
+	if data > maxFork || res > maxFork {
+		return 0
+	}
+	if length > 0 {
+		end := macBinaryLen + data
+		if res > 0 {
+			end = macBinaryLen + (data+macBinaryLen-1)/macBinaryLen*macBinaryLen + res
+		}
+		if end > length {
+			return 0
+		}
+	}
+	if macCRC(p[:124]) == be.Uint16(p[124:]) {
+		if bytes.Equal(p[102:106], []byte("mBIN")) {
+			return 3
+		}
+		return 2
+	}
+	// MacBinary I has no checksum and the end of the header is zero filled
+	if bytes.Count(p[99:macBinaryLen], []byte{0}) == macBinaryLen-99 {
+		return 1
+	}
+	return 0
+}
+
+// macCRC returns the CRC-16/XMODEM checksum that is used by MacBinary II and BinHex.
+func macCRC(p []byte) uint16 {
+	const poly = 0x1021
+	crc := uint16(0)
+	for _, b := range p {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ poly
+				continue
+			}
+			crc <<= 1
+		}
+	}
+	return crc
+}
+
+// macName returns true if the Macintosh filename has no control characters or colons,
+// as the colon is the path separator of the classic Mac OS.
+func macName(b []byte) bool {
+	for _, c := range b {
+		if c < ' ' || c == ':' {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+// macRoman decodes the Mac OS Roman encoded bytes.
+func macRoman(b []byte) string {
+	s, err := charmap.Macintosh.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(s)
+}
+
+// macTime returns the date of the seconds since the start of 1904, or zero when the seconds are 0.
+func macTime(secs uint32) time.Time {
+	if secs == 0 {
+		return time.Time{}
+	}
+	return time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(secs) * time.Second)
+}
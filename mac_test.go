@@ -0,0 +1,195 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+// xmodem returns the CRC-16/XMODEM checksum of the MacBinary II header.
+func xmodem(p []byte) uint16 {
+	crc := uint16(0)
+	for _, b := range p {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// macBinary returns a MacBinary file of the version with the data and resource forks.
+func macBinary(version int, name string, data, res []byte) []byte {
+	p := make([]byte, 128)
+	p[1] = byte(len(name))
+	copy(p[2:], name)
+	copy(p[65:], "PNGfogle")
+	binary.BigEndian.PutUint32(p[83:], uint32(len(data)))
+	binary.BigEndian.PutUint32(p[87:], uint32(len(res)))
+	// 1993-04-01 in seconds since 1904
+	binary.BigEndian.PutUint32(p[91:], 2816467200)
+	binary.BigEndian.PutUint32(p[95:], 2816467200)
+	if version > 1 {
+		p[122], p[123] = 129, 129
+		if version == 3 {
+			copy(p[102:], "mBIN")
+			p[122] = 130
+		}
+		binary.BigEndian.PutUint16(p[124:], xmodem(p[:124]))
+	}
+	b := append(p, data...)
+	b = append(b, make([]byte, (128-len(data)%128)%128)...)
+	return append(b, res...)
+}
+
+func TestMacBinary(t *testing.T) {
+	t.Parallel()
+	t.Log("TestMacBinary")
+	_, err := magicnumber.MacHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	png, err := os.ReadFile(uncompress(pngFile))
+	be.Err(t, err, nil)
+	for _, version := range []int{1, 2, 3} {
+		b := macBinary(version, "Logo \xc4.png", png, []byte("resource fork"))
+		r := bytes.NewReader(b)
+		be.Equal(t, magicnumber.MacBinary, magicnumber.Find(r))
+		mac, err := magicnumber.MacHeaders(r)
+		be.Err(t, err, nil)
+		be.Equal(t, version, mac.Version)
+		be.Equal(t, "Logo ƒ.png", mac.Name)
+		be.Equal(t, "PNGf", mac.Type)
+		be.Equal(t, "ogle", mac.Creator)
+		be.Equal(t, time.Date(1993, time.April, 1, 0, 0, 0, 0, time.UTC), mac.Modified)
+		be.Equal(t, int64(128), mac.DataOffset)
+		be.Equal(t, int64(len(png)), mac.DataLength)
+		be.Equal(t, int64(13), mac.ResourceLength)
+		be.Equal(t, magicnumber.PortableNetworkGraphics, magicnumber.Find(mac.DataFork(r)))
+		p := make([]byte, mac.ResourceLength)
+		_, err = mac.ResourceFork(r).Read(p)
+		be.Err(t, err, nil)
+		be.Equal(t, "resource fork", string(p))
+	}
+	// a MacBinary II header with a bad checksum is not a MacBinary I header
+	b := macBinary(2, "README", []byte("hello"), nil)
+	b[124] ^= 0xff
+	be.True(t, !magicnumber.MacBin(bytes.NewReader(b)))
+	// a data fork length that is larger than the file
+	b = macBinary(1, "README", []byte("hello"), nil)
+	be.True(t, magicnumber.MacBin(bytes.NewReader(b)))
+	binary.BigEndian.PutUint32(b[83:], 1000)
+	be.True(t, !magicnumber.MacBin(bytes.NewReader(b)))
+}
+
+// appleFile returns an AppleSingle or AppleDouble file of the entries.
+func appleFile(magic uint32, entries map[uint32][]byte, ids ...uint32) []byte {
+	be := binary.BigEndian
+	b := be.AppendUint32(nil, magic)
+	b = be.AppendUint32(b, 0x00020000)
+	b = append(b, make([]byte, 16)...)
+	b = be.AppendUint16(b, uint16(len(ids)))
+	offset := len(b) + len(ids)*12
+	var data []byte
+	for _, id := range ids {
+		b = be.AppendUint32(b, id)
+		b = be.AppendUint32(b, uint32(offset+len(data)))
+		b = be.AppendUint32(b, uint32(len(entries[id])))
+		data = append(data, entries[id]...)
+	}
+	return append(b, data...)
+}
+
+func TestAppleSingle(t *testing.T) {
+	t.Parallel()
+	t.Log("TestAppleSingle")
+	png, err := os.ReadFile(uncompress(pngFile))
+	be.Err(t, err, nil)
+	// 1997-01-01 in seconds before the year 2000
+	dates := binary.BigEndian.AppendUint32(nil, uint32(0xffffffff-94608000+1))
+	dates = binary.BigEndian.AppendUint32(dates, 0x80000000)
+	entries := map[uint32][]byte{
+		1: png,
+		2: []byte("resource"),
+		3: []byte("Caf\x8e"),
+		8: append(dates, make([]byte, 8)...),
+		9: append([]byte("PNGfogle"), make([]byte, 24)...),
+	}
+	b := appleFile(0x00051600, entries, 3, 9, 8, 2, 1)
+	r := bytes.NewReader(b)
+	be.Equal(t, magicnumber.AppleSingle, magicnumber.Find(r))
+	mac, err := magicnumber.MacHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.AppleSingle, mac.Signature)
+	be.Equal(t, 2, mac.Version)
+	be.Equal(t, "Café", mac.Name)
+	be.Equal(t, "PNGf", mac.Type)
+	be.Equal(t, "ogle", mac.Creator)
+	be.Equal(t, time.Date(1997, time.January, 1, 0, 0, 0, 0, time.UTC), mac.Created)
+	be.True(t, mac.Modified.IsZero())
+	be.Equal(t, int64(8), mac.ResourceLength)
+	be.Equal(t, int64(len(png)), mac.DataLength)
+	be.Equal(t, magicnumber.PortableNetworkGraphics, magicnumber.Find(mac.DataFork(r)))
+
+	b = appleFile(0x00051607, entries, 9, 2)
+	r = bytes.NewReader(b)
+	be.Equal(t, magicnumber.AppleDouble, magicnumber.Find(r))
+	mac, err = magicnumber.MacHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.AppleDouble, mac.Signature)
+	be.Equal(t, "PNGf", mac.Type)
+	be.Equal(t, int64(0), mac.DataLength)
+	be.Equal(t, int64(8), mac.ResourceLength)
+	// AppleDouble files are named with a "._" prefix and have no extension of their own
+	be.Equal(t, 0, len((*magicnumber.Ext())[magicnumber.AppleDouble]))
+
+	_, err = magicnumber.MacHeaders(bytes.NewReader(png))
+	be.Err(t, err, magicnumber.ErrMacHeader)
+}
+
+func TestMacArchives(t *testing.T) {
+	t.Parallel()
+	t.Log("TestMacArchives")
+	sit := append([]byte("SIT!\x00\x01\x00\x00\x01\x00rLau\x01"), make([]byte, 8)...)
+	sit5 := []byte("StuffIt (c)1997-2002 Aladdin Systems, Inc., http://www.aladdinsys.com/StuffIt/\r\n\x1a\x00")
+	// a Compact Pro archive with the compressed data at offset 8 and the directory at offset 16
+	cpt := []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10}
+	cpt = append(cpt, make([]byte, 8)...)
+	cpt = append(cpt, 0, 0, 0, 0, 0, 1, 0)
+	cpt = append(cpt, 9)
+	cpt = append(cpt, "ReadMe ƒ"...)
+	cpt = append(cpt, 0, 0, 0, 0, 8)
+	cpt = append(cpt, make([]byte, 40)...)
+	hqx := []byte("From: sysop\r\n\r\n(This file must be converted with BinHex 4.0)\r\n:$f*TEQKPH#jdCA0d,R0TG!\"6594%8dP8)3#3\"!%q!!!!!!:\r\n")
+	tests := []struct {
+		name string
+		b    []byte
+		sign magicnumber.Signature
+	}{
+		{"StuffIt", sit, magicnumber.StuffItArchive},
+		{"StuffIt 5", sit5, magicnumber.StuffIt5Archive},
+		{"Compact Pro", cpt, magicnumber.CompactProArchive},
+		{"BinHex", hqx, magicnumber.BinHex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := bytes.NewReader(tt.b)
+			be.Equal(t, tt.sign, magicnumber.Find(r))
+			sign, err := magicnumber.Archive(r)
+			be.Err(t, err, nil)
+			be.Equal(t, tt.sign, sign)
+		})
+	}
+	// the Compact Pro directory must point to a valid entry
+	bad := bytes.Clone(cpt)
+	bad[7] = 0x11
+	be.True(t, !magicnumber.CompactPro(bytes.NewReader(bad)))
+}
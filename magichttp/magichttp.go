@@ -0,0 +1,280 @@
+// Package magichttp is an optional net/http handler that exposes the magicnumber
+// file type detection as a local web service, for tools not written in Go.
+//
+// The handler serves two endpoints that both return JSON.
+//   - POST /detect reads the request body as the file, an optional filename is given by the name query.
+//   - POST /detect/multipart reads every file part of a multipart/form-data request.
+package magichttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Defacto2/magicnumber"
+)
+
+// DefaultMaxBytes is the default maximum size of a request body, 32 MiB.
+const DefaultMaxBytes = 32 << 20
+
+var (
+	ErrEmpty    = errors.New("the request contains no file data")
+	ErrMethod   = errors.New("the request method is not allowed, use POST")
+	ErrTooLarge = errors.New("the request body is too large")
+)
+
+// Result is the file type detection of a file.
+type Result struct {
+	Name      string            `json:"name,omitempty"`
+	Size      int64             `json:"size"`
+	Signature string            `json:"signature"`
+	Title     string            `json:"title"`
+	Category  string            `json:"category"`
+	Encrypted string            `json:"encrypted,omitempty"`
+	Extension *Verdict          `json:"extension,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Verdict is the comparison of the filename extension with the file type signature.
+type Verdict struct {
+	Name     string   `json:"name"`     // Name is the extension of the filename
+	Valid    bool     `json:"valid"`    // Valid is true if the extension is expected for the signature
+	Expected []string `json:"expected"` // Expected are the common extensions for the signature
+}
+
+// Detect returns the file type detection of the reader.
+// The name is optional and is only used to compare the extension to the file type signature.
+func Detect(name string, r io.ReaderAt, size int64) Result {
+	sign := magicnumber.Find(r)
+	res := Result{
+		Name:      name,
+		Size:      size,
+		Signature: sign.String(),
+		Title:     sign.Title(),
+		Category:  sign.Category().String(),
+		Metadata:  Metadata(sign, r),
+	}
+	if enc, _ := magicnumber.Encrypted(r); enc != magicnumber.NotEncrypted {
+		res.Encrypted = enc.String()
+	}
+	if name == "" {
+		return res
+	}
+	// the extension is valid for any signature that matches the reader,
+	// such as .exe for a PKLITE compressed program that is found as a PKLITE archive
+	exts := *magicnumber.Ext()
+	expected := slices.Clone(exts[sign])
+	if expected == nil {
+		expected = []string{}
+	}
+	valid, match, _ := magicnumber.MatchExt(name, r)
+	if valid && match != sign {
+		expected = append(expected, exts[match]...)
+	}
+	res.Extension = &Verdict{
+		Name:     strings.ToLower(filepath.Ext(name)),
+		Valid:    valid,
+		Expected: expected,
+	}
+	return res
+}
+
+// Metadata returns the descriptive information that the magicnumber package can read
+// from the file type signature, such as the song title of music, the Windows version
+// of a program or the comment and creator of an archive. It returns nil when there is no metadata.
+func Metadata(sign magicnumber.Signature, r io.ReaderAt) map[string]string {
+	meta := map[string]string{}
+	switch sign {
+	case magicnumber.MicrosoftExecutable:
+		win, err := magicnumber.FindExecutable(r)
+		if err == nil && (win.NE != magicnumber.NoneNE || win.PE != magicnumber.UnknownPE) {
+			meta["executable"] = win.String()
+		}
+	case magicnumber.MPEG1AudioLayer3:
+		if s := magicnumber.MusicID3v2(r); s != "" {
+			meta["song"] = s
+		} else if s := magicnumber.MusicID3v1(r); s != "" {
+			meta["song"] = s
+		}
+	case magicnumber.MusicExtendedModule, magicnumber.MusicMultiTrackModule,
+		magicnumber.MusicImpulseTracker, magicnumber.MusicProTracker:
+		if s := magicnumber.MusicTracker(r); s != "" {
+			meta["song"] = s
+		}
+	case magicnumber.InterleavedBitmap:
+		if w, h := magicnumber.IlbmDecode(r); w > 0 && h > 0 {
+			meta["width"] = strconv.Itoa(w)
+			meta["height"] = strconv.Itoa(h)
+		}
+	case magicnumber.PKWAREZip, magicnumber.PKWAREZip64, magicnumber.PKWAREZipShrink,
+		magicnumber.PKWAREZipReduce, magicnumber.PKWAREZipImplode:
+		if c, err := magicnumber.ZipFingerprint(r); err == nil && c.Program != magicnumber.ZipUnknown {
+			meta["creator"] = c.Program.String()
+		}
+	}
+	if sign.Category() == magicnumber.ArchiveCategory {
+		if c, err := magicnumber.Comments(r); err == nil && strings.TrimSpace(c.Archive) != "" {
+			meta["comment"] = c.Archive
+		}
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// Handler is the file type detection service.
+type Handler struct {
+	MaxBytes int64 // MaxBytes is the maximum size of a request body, a value of 0 uses DefaultMaxBytes
+}
+
+// New returns a new Handler using the DefaultMaxBytes request size limit.
+func New() *Handler {
+	return &Handler{MaxBytes: DefaultMaxBytes}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/detect", "/detect/multipart":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		Error(w, http.StatusMethodNotAllowed, ErrMethod)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.limit())
+	if r.URL.Path == "/detect/multipart" {
+		results, err := Multipart(r)
+		if err != nil {
+			Error(w, status(err), err)
+			return
+		}
+		JSON(w, http.StatusOK, results)
+		return
+	}
+	res, err := Body(r)
+	if err != nil {
+		Error(w, status(err), err)
+		return
+	}
+	JSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) limit() int64 {
+	if h.MaxBytes <= 0 {
+		return DefaultMaxBytes
+	}
+	return h.MaxBytes
+}
+
+// Body reads the request body as a file and returns the detection result.
+// The filename is taken from the name query, for example, /detect?name=FILE_ID.DIZ.
+func Body(r *http.Request) (Result, error) {
+	p, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Result{}, readErr(err)
+	}
+	if len(p) == 0 {
+		return Result{}, ErrEmpty
+	}
+	name := filepath.Base(r.URL.Query().Get("name"))
+	if name == "." || name == string(filepath.Separator) {
+		name = ""
+	}
+	return Detect(name, bytes.NewReader(p), int64(len(p))), nil
+}
+
+// Multipart reads every file part of a multipart/form-data request and returns
+// the detection results in the order of the parts. Form values that are not files are ignored.
+func Multipart(r *http.Request) ([]Result, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, readErr(err)
+	}
+	files, err := readParts(mr)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(files))
+	for _, f := range files {
+		results = append(results, Detect(f.name, bytes.NewReader(f.data), int64(len(f.data))))
+	}
+	return results, nil
+}
+
+// file is an uploaded file read from a multipart part.
+type file struct {
+	name string
+	data []byte
+}
+
+// readParts reads the file parts of the multipart reader, skipping the form values and empty files.
+func readParts(mr *multipart.Reader) ([]file, error) {
+	files := []file{}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, readErr(err)
+		}
+		if part.FileName() == "" {
+			_ = part.Close()
+			continue
+		}
+		p, err := io.ReadAll(part)
+		_ = part.Close()
+		if err != nil {
+			return nil, readErr(err)
+		}
+		if len(p) == 0 {
+			continue
+		}
+		files = append(files, file{name: filepath.Base(part.FileName()), data: p})
+	}
+	if len(files) == 0 {
+		return nil, ErrEmpty
+	}
+	return files, nil
+}
+
+func readErr(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return fmt.Errorf("%w, the limit is %d bytes", ErrTooLarge, maxErr.Limit)
+	}
+	return fmt.Errorf("magichttp read request: %w", err)
+}
+
+func status(err error) int {
+	if errors.Is(err, ErrTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// JSON writes the value as a JSON response with the status code.
+func JSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// Error writes the error as a JSON response with the status code.
+func Error(w http.ResponseWriter, code int, err error) {
+	JSON(w, code, map[string]string{"error": err.Error()})
+}
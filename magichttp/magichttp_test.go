@@ -0,0 +1,151 @@
+package magichttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber/magichttp"
+	"github.com/nalgeon/be"
+)
+
+func tdfile(name ...string) string {
+	return filepath.Join(append([]string{"..", "testdata"}, name...)...)
+}
+
+// pklite returns a DOS program that is compressed by PKLITE, which is found as a PKLITE archive.
+func pklite() []byte {
+	b := make([]byte, 512)
+	copy(b, "MZ")
+	copy(b[30:], "PKLITE Copr. 1990-92 PKWARE Inc. All Rights Reserved")
+	return b
+}
+
+func TestPklite(t *testing.T) {
+	t.Parallel()
+	p := pklite()
+	res := magichttp.Detect("FOO.EXE", bytes.NewReader(p), int64(len(p)))
+	be.Equal(t, "pklite compressed", res.Signature)
+	be.True(t, res.Extension != nil)
+	be.Equal(t, ".exe", res.Extension.Name)
+	be.True(t, res.Extension.Valid)
+	be.Equal(t, []string{".zip", ".exe"}, res.Extension.Expected)
+	// the verdict is the same for every request
+	for range 50 {
+		again := magichttp.Detect("FOO.EXE", bytes.NewReader(p), int64(len(p)))
+		be.Equal(t, res.Extension, again.Extension)
+	}
+
+	res = magichttp.Detect("FOO.TXT", bytes.NewReader(p), int64(len(p)))
+	be.True(t, !res.Extension.Valid)
+	be.Equal(t, []string{".zip"}, res.Extension.Expected)
+}
+
+func TestBody(t *testing.T) {
+	t.Parallel()
+	p, err := os.ReadFile(tdfile("uncompress", "TEST.PNG"))
+	be.Err(t, err, nil)
+	srv := httptest.NewServer(magichttp.New())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/detect?name=TEST.JPG", "application/octet-stream", bytes.NewReader(p))
+	be.Err(t, err, nil)
+	defer resp.Body.Close()
+	be.Equal(t, http.StatusOK, resp.StatusCode)
+	var res magichttp.Result
+	be.Err(t, json.NewDecoder(resp.Body).Decode(&res), nil)
+	be.Equal(t, "TEST.JPG", res.Name)
+	be.Equal(t, int64(len(p)), res.Size)
+	be.Equal(t, "PNG image", res.Signature)
+	be.Equal(t, "image", res.Category)
+	be.True(t, res.Extension != nil)
+	be.Equal(t, ".jpg", res.Extension.Name)
+	be.True(t, !res.Extension.Valid)
+	be.Equal(t, []string{".png"}, res.Extension.Expected)
+}
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+	p, err := os.ReadFile(tdfile("mp3", "id3v2_001_basic.mp3"))
+	be.Err(t, err, nil)
+	res := magichttp.Detect("", bytes.NewReader(p), int64(len(p)))
+	be.Equal(t, "MP3 audio", res.Signature)
+	be.True(t, res.Extension == nil)
+	be.Equal(t, "Title by Artist (2003)", res.Metadata["song"])
+	be.Equal(t, "", res.Encrypted)
+
+	p, err = os.ReadFile(tdfile("τεχτƒιℓε.encrypted.zip"))
+	be.Err(t, err, nil)
+	res = magichttp.Detect("", bytes.NewReader(p), int64(len(p)))
+	be.Equal(t, "WinZip AES", res.Encrypted)
+
+	p, err = os.ReadFile(tdfile("PKZ110.ZIP"))
+	be.Err(t, err, nil)
+	res = magichttp.Detect("", bytes.NewReader(p), int64(len(p)))
+	be.Equal(t, "PKZIP 1.1x", res.Metadata["creator"])
+}
+
+func TestMultipart(t *testing.T) {
+	t.Parallel()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	be.Err(t, mw.WriteField("comment", "ignored"), nil)
+	for _, name := range []string{"TEST.cab", "ARJ310.ARJ"} {
+		p, err := os.ReadFile(tdfile(name))
+		be.Err(t, err, nil)
+		fw, err := mw.CreateFormFile("file", name)
+		be.Err(t, err, nil)
+		_, err = fw.Write(p)
+		be.Err(t, err, nil)
+	}
+	be.Err(t, mw.Close(), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/detect/multipart", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	magichttp.New().ServeHTTP(rec, req)
+	be.Equal(t, http.StatusOK, rec.Code)
+	var results []magichttp.Result
+	be.Err(t, json.NewDecoder(rec.Body).Decode(&results), nil)
+	be.Equal(t, 2, len(results))
+	be.Equal(t, "Microsoft cabinet", results[0].Signature)
+	be.Equal(t, "ARJ archive", results[1].Signature)
+	be.True(t, results[1].Extension.Valid)
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+	h := &magichttp.Handler{MaxBytes: 16}
+	req := httptest.NewRequest(http.MethodPost, "/detect", strings.NewReader(strings.Repeat("x", 17)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	be.True(t, strings.Contains(rec.Body.String(), "too large"))
+
+	req = httptest.NewRequest(http.MethodPost, "/detect", strings.NewReader(""))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/detect", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	be.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+
+	req = httptest.NewRequest(http.MethodPost, "/unknown", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusNotFound, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/detect/multipart", strings.NewReader("not multipart"))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusBadRequest, rec.Code)
+}
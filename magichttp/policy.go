@@ -0,0 +1,180 @@
+package magichttp
+
+// Package file policy.go contains the upload policy and the middleware that enforces it.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Defacto2/magicnumber"
+)
+
+var errBoundary = errors.New("the multipart request has no boundary")
+
+// Code is the machine readable reason for an upload rejection.
+type Code string
+
+const (
+	SignatureDenied   Code = "signature-denied"   // the file type signature is in the Deny list
+	CategoryDenied    Code = "category-denied"    // the category of the signature is in the DenyCategories list
+	NotAllowed        Code = "not-allowed"        // the signature and category are not in the allow lists
+	ExtensionMismatch Code = "extension-mismatch" // the filename extension does not match the signature
+)
+
+// Policy allows or denies uploads by the file type signature, the category of the
+// signature or a mismatch between the filename extension and the signature.
+//
+// The rules are checked in order, the Deny and DenyCategories lists, followed by the
+// Allow and AllowCategories lists and then the DenyMismatch option. When both of the
+// allow lists are empty, any signature that is not denied is allowed.
+type Policy struct {
+	Allow           []magicnumber.Signature // Allow only these signatures, in addition to AllowCategories
+	AllowCategories []magicnumber.Category  // AllowCategories only allows the signatures of these categories
+	Deny            []magicnumber.Signature // Deny these signatures, for example magicnumber.Unknown
+	DenyCategories  []magicnumber.Category  // DenyCategories denies the signatures of these categories
+	DenyMismatch    bool                    // DenyMismatch denies files with an extension that does not match
+	MaxBytes        int64                   // MaxBytes read by the Middleware, a value of 0 uses DefaultMaxBytes
+}
+
+// Rejection is the structured reason for a denied upload.
+type Rejection struct {
+	Code      Code     `json:"code"`
+	Reason    string   `json:"reason"`
+	Name      string   `json:"name,omitempty"`
+	Signature string   `json:"signature"`
+	Category  string   `json:"category"`
+	Extension string   `json:"extension,omitempty"`
+	Expected  []string `json:"expected,omitempty"`
+}
+
+func (r *Rejection) Error() string {
+	return r.Reason
+}
+
+// Check identifies the file type of the reader and returns the signature and nil if the
+// policy allows the upload, otherwise it returns the signature and the reason for the rejection.
+// The name is the optional filename of the upload and is required to check for a mismatch.
+func (p Policy) Check(name string, r io.ReaderAt) (magicnumber.Signature, *Rejection) {
+	sign := magicnumber.Find(r)
+	return sign, p.check(name, r, sign)
+}
+
+func (p Policy) check(name string, r io.ReaderAt, sign magicnumber.Signature) *Rejection {
+	cat := sign.Category()
+	reject := func(code Code, reason string) *Rejection {
+		return &Rejection{
+			Code:      code,
+			Reason:    reason,
+			Name:      name,
+			Signature: sign.String(),
+			Category:  cat.String(),
+		}
+	}
+	switch {
+	case slices.Contains(p.Deny, sign):
+		return reject(SignatureDenied, fmt.Sprintf("%s files are not accepted", sign))
+	case slices.Contains(p.DenyCategories, cat):
+		return reject(CategoryDenied, fmt.Sprintf("%s files are not accepted", cat))
+	case len(p.Allow) > 0 || len(p.AllowCategories) > 0:
+		if !slices.Contains(p.Allow, sign) && !slices.Contains(p.AllowCategories, cat) {
+			return reject(NotAllowed, fmt.Sprintf("%s files are not in the accepted file types", sign))
+		}
+	}
+	if !p.DenyMismatch || name == "" {
+		return nil
+	}
+	expected := (*magicnumber.Ext())[sign]
+	if len(expected) == 0 {
+		return nil
+	}
+	// the extension of any signature that matches the reader is accepted,
+	// so a PKLITE compressed program can use the .exe extension
+	if valid, _, _ := magicnumber.MatchExt(name, r); valid {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	rej := reject(ExtensionMismatch,
+		fmt.Sprintf("the %q extension does not match the %s content, expected %s",
+			ext, sign, strings.Join(expected, ", ")))
+	rej.Extension = ext
+	rej.Expected = expected
+	return rej
+}
+
+// Middleware returns a handler that checks the uploaded files against the policy before
+// calling the next handler. The POST, PUT and PATCH request bodies are read into memory,
+// up to the MaxBytes limit, and restored for the next handler.
+//
+// A multipart/form-data request checks every file part and a form without files is passed through,
+// while any other content type checks the body as a single file, using the name query as the optional filename.
+// A denied upload is answered with a 415 Unsupported Media Type status and the
+// [Rejection] as JSON.
+func (p Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		limit := p.MaxBytes
+		if limit <= 0 {
+			limit = DefaultMaxBytes
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			err = readErr(err)
+			Error(w, status(err), err)
+			return
+		}
+		_ = r.Body.Close()
+		if rej, err := p.request(r, body); err != nil {
+			Error(w, status(err), err)
+			return
+		} else if rej != nil {
+			JSON(w, http.StatusUnsupportedMediaType, rej)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// request checks the files in the request body and returns the first rejection.
+func (p Policy) request(r *http.Request, body []byte) (*Rejection, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		name := filepath.Base(r.URL.Query().Get("name"))
+		if name == "." || name == string(filepath.Separator) {
+			name = ""
+		}
+		_, rej := p.Check(name, bytes.NewReader(body))
+		return rej, nil
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, errBoundary
+	}
+	files, err := readParts(multipart.NewReader(bytes.NewReader(body), boundary))
+	if errors.Is(err, ErrEmpty) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if _, rej := p.Check(f.name, bytes.NewReader(f.data)); rej != nil {
+			return rej, nil
+		}
+	}
+	return nil, nil
+}
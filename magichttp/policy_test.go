@@ -0,0 +1,123 @@
+package magichttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/Defacto2/magicnumber/magichttp"
+	"github.com/nalgeon/be"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	t.Parallel()
+	p, err := os.ReadFile(tdfile("ARJ310.ARJ"))
+	be.Err(t, err, nil)
+	r := bytes.NewReader(p)
+
+	sign, rej := magichttp.Policy{}.Check("ARJ310.ARJ", r)
+	be.Equal(t, magicnumber.ArchiveRobertJung, sign)
+	be.True(t, rej == nil)
+
+	pol := magichttp.Policy{Deny: []magicnumber.Signature{magicnumber.ArchiveRobertJung}}
+	_, rej = pol.Check("ARJ310.ARJ", r)
+	be.Equal(t, magichttp.SignatureDenied, rej.Code)
+
+	pol = magichttp.Policy{DenyCategories: []magicnumber.Category{magicnumber.ArchiveCategory}}
+	_, rej = pol.Check("ARJ310.ARJ", r)
+	be.Equal(t, magichttp.CategoryDenied, rej.Code)
+	be.Equal(t, "archive", rej.Category)
+
+	pol = magichttp.Policy{AllowCategories: []magicnumber.Category{magicnumber.ImageCategory}}
+	_, rej = pol.Check("ARJ310.ARJ", r)
+	be.Equal(t, magichttp.NotAllowed, rej.Code)
+
+	pol = magichttp.Policy{
+		Allow:           []magicnumber.Signature{magicnumber.ArchiveRobertJung},
+		AllowCategories: []magicnumber.Category{magicnumber.ImageCategory},
+		DenyMismatch:    true,
+	}
+	_, rej = pol.Check("ARJ310.ARJ", r)
+	be.True(t, rej == nil)
+	_, rej = pol.Check("ARJ310.ZIP", r)
+	be.Equal(t, magichttp.ExtensionMismatch, rej.Code)
+	be.Equal(t, ".zip", rej.Extension)
+	be.Equal(t, []string{".arj"}, rej.Expected)
+	be.True(t, rej.Error() != "")
+
+	// a PKLITE compressed program is a valid .exe file
+	p = pklite()
+	pol = magichttp.Policy{DenyMismatch: true}
+	_, rej = pol.Check("FOO.EXE", bytes.NewReader(p))
+	be.True(t, rej == nil)
+	_, rej = pol.Check("FOO.TXT", bytes.NewReader(p))
+	be.Equal(t, magichttp.ExtensionMismatch, rej.Code)
+}
+
+func TestPolicyMiddleware(t *testing.T) {
+	t.Parallel()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(p)
+	})
+	pol := magichttp.Policy{
+		DenyCategories: []magicnumber.Category{magicnumber.ProgramCategory},
+		Deny:           []magicnumber.Signature{magicnumber.Unknown},
+		DenyMismatch:   true,
+	}
+	h := pol.Middleware(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/upload?name=hello.txt", strings.NewReader("hello world"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusCreated, rec.Code)
+	be.Equal(t, "hello world", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/upload?name=hello.exe", strings.NewReader("hello world"))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	var rej magichttp.Rejection
+	be.Err(t, json.NewDecoder(rec.Body).Decode(&rej), nil)
+	be.Equal(t, magichttp.ExtensionMismatch, rej.Code)
+	be.Equal(t, "hello.exe", rej.Name)
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", "README.TXT")
+	be.Err(t, err, nil)
+	_, err = fw.Write([]byte("a plain text readme"))
+	be.Err(t, err, nil)
+	fw, err = mw.CreateFormFile("file", "SETUP.EXE")
+	be.Err(t, err, nil)
+	_, err = fw.Write(append([]byte("MZ"), make([]byte, 64)...))
+	be.Err(t, err, nil)
+	be.Err(t, mw.Close(), nil)
+	req = httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	be.Err(t, json.NewDecoder(rec.Body).Decode(&rej), nil)
+	be.Equal(t, magichttp.CategoryDenied, rej.Code)
+	be.Equal(t, "SETUP.EXE", rej.Name)
+
+	req = httptest.NewRequest(http.MethodGet, "/upload", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusCreated, rec.Code)
+
+	small := magichttp.Policy{MaxBytes: 4}.Middleware(next)
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello world"))
+	rec = httptest.NewRecorder()
+	small.ServeHTTP(rec, req)
+	be.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
@@ -29,6 +29,9 @@ import (
 
 var ErrNilReader = errors.New("nil reader")
 
+// maxBlocks is the maximum number of archive headers that are walked.
+const maxBlocks = 100000
+
 // Signature represents a file type signature.
 type Signature int
 
@@ -108,9 +111,31 @@ const (
 	PlanarBitMap
 	NoGatePAK
 	XBinaryText
+	ACEArchive
+	HarriHirvolaHA
+	HyperArchive
+	SqueezeItArchive
+	UltraCompressorII
+	DeanCooperDWC
+	LimitArchive
+	CPMLibrary
+	CPMSqueeze
+	CPMCrunch
+	CPMCrunchLZH
+	StuffItArchive
+	StuffIt5Archive
+	CompactProArchive
+	MacBinary
+	AppleSingle
+	AppleDouble
+	BinHex
+	AmigaLZX
+	AmigaDMS
+	AmigaADF
+	PowerPacker
 )
 
-const LastSignature = XBinaryText
+const LastSignature = PowerPacker
 
 const (
 	mpeg4video = "MPEG-4 video"
@@ -203,6 +228,28 @@ func (sign Signature) String() string { //nolint:funlen
 		"IFF PBM image",
 		"PAK archive",
 		"XBIN binary text",
+		"ACE archive",
+		"HA archive",
+		"HYP archive",
+		"SQZ archive",
+		"UC2 archive",
+		"DWC archive",
+		"LIMIT archive",
+		"LBR archive",
+		"Squeezed file",
+		"Crunched file",
+		"CRLZH file",
+		"StuffIt archive",
+		"StuffIt 5 archive",
+		"Compact Pro archive",
+		"MacBinary file",
+		"AppleSingle file",
+		"AppleDouble file",
+		"BinHex text",
+		"LZX archive",
+		"DMS disk image",
+		"ADF disk image",
+		"PowerPacker data",
 	}[sign]
 }
 
@@ -289,6 +336,28 @@ func (sign Signature) Title() string { //nolint:funlen
 		"IFF Planar BitMap",
 		"PAK archive by NoGate",
 		"XBIN extended binary text",
+		"ACE archive by Marcel Lemke",
+		"HA archive by Harri Hirvola",
+		"Hyper archive",
+		"Squeeze It archive",
+		"UltraCompressor II archive",
+		"DWC archive by Dean W. Cooper",
+		"LIMIT archive",
+		"CP/M LBR library",
+		"Squeeze compressed file",
+		"Crunch compressed file",
+		"CRLZH compressed file",
+		"StuffIt archive by Raymond Lau",
+		"StuffIt 5 archive by Aladdin Systems",
+		"Compact Pro archive by Bill Goodman",
+		"MacBinary encoded Macintosh file",
+		"AppleSingle encoded Macintosh file",
+		"AppleDouble encoded Macintosh file",
+		"BinHex 4.0 encoded Macintosh file",
+		"LZX archive for the Amiga",
+		"Disk Masher compressed Amiga disk",
+		"Amiga Disk File floppy image",
+		"PowerPacker compressed Amiga data",
 	}[sign]
 }
 
@@ -352,7 +421,7 @@ func Ext() *Extension { //nolint:funlen
 		ArchiveRobertJung:                 []string{".arj"},
 		MicrosoftCABinet:                  []string{".cab"},
 		MicrosoftDOSKWAJ:                  []string{".com"},
-		MicrosoftDOSSZDD:                  []string{eexe},
+		MicrosoftDOSSZDD:                  []string{".ex_", ".dl_", ".co_", ".sy_", ".dr_", ".tx_"},
 		MicrosoftExecutable:               []string{eexe},
 		MicrosoftCompoundFile:             []string{eexe},
 		CDISO9660:                         []string{".iso"},
@@ -366,11 +435,33 @@ func Ext() *Extension { //nolint:funlen
 		UTF16Text:                         []string{ttxt},
 		UTF32Text:                         []string{ttxt},
 		ANSIEscapeText:                    []string{".ans"},
-		PlainText:                         []string{ttxt},
+		PlainText:                         []string{ttxt, ".nfo", ".diz", ".asc", ".me", ".1st"},
 		ElectronicArtsAnim:                []string{iiff, ".anm"},
 		PlanarBitMap:                      []string{iiff, ".lbm"},
 		NoGatePAK:                         []string{".pak"},
 		XBinaryText:                       []string{".xb", ".bin"},
+		ACEArchive:                        []string{".ace"},
+		HarriHirvolaHA:                    []string{".ha"},
+		HyperArchive:                      []string{".hyp"},
+		SqueezeItArchive:                  []string{".sqz"},
+		UltraCompressorII:                 []string{".uc2"},
+		DeanCooperDWC:                     []string{".dwc"},
+		LimitArchive:                      []string{".lim"},
+		CPMLibrary:                        []string{".lbr"},
+		CPMSqueeze:                        []string{".tqt", ".dqc", ".aqm", ".cqm", ".lqr"},
+		CPMCrunch:                         []string{".tzt", ".dzc", ".azm", ".czm", ".lzr"},
+		CPMCrunchLZH:                      []string{".tyt", ".dyc", ".aym", ".cym", ".lyr"},
+		StuffItArchive:                    []string{".sit"},
+		StuffIt5Archive:                   []string{".sit"},
+		CompactProArchive:                 []string{".cpt"},
+		MacBinary:                         []string{".bin", ".macbin"},
+		AppleSingle:                       []string{".as"},
+		AppleDouble:                       []string{},
+		BinHex:                            []string{".hqx"},
+		AmigaLZX:                          []string{".lzx"},
+		AmigaDMS:                          []string{".dms"},
+		AmigaADF:                          []string{".adf"},
+		PowerPacker:                       []string{".pp"},
 	}
 	return &exts
 }
@@ -458,10 +549,136 @@ func New() *Finder { //nolint:funlen
 		PlanarBitMap:                      IffPBM,
 		NoGatePAK:                         Pak,
 		XBinaryText:                       XBin,
+		ACEArchive:                        Ace,
+		HarriHirvolaHA:                    Ha,
+		HyperArchive:                      Hyp,
+		SqueezeItArchive:                  Sqz,
+		UltraCompressorII:                 Uc2,
+		DeanCooperDWC:                     Dwc,
+		LimitArchive:                      Limit,
+		CPMLibrary:                        Lbr,
+		CPMSqueeze:                        Squeeze,
+		CPMCrunch:                         Crunch,
+		CPMCrunchLZH:                      CrunchLZH,
+		StuffItArchive:                    StuffIt,
+		StuffIt5Archive:                   StuffIt5,
+		CompactProArchive:                 CompactPro,
+		MacBinary:                         MacBin,
+		AppleSingle:                       AppleSingleFile,
+		AppleDouble:                       AppleDoubleFile,
+		BinHex:                            BinHex4,
+		AmigaLZX:                          Lzx,
+		AmigaDMS:                          Dms,
+		AmigaADF:                          Adf,
+		PowerPacker:                       PowerPack,
 	}
 	return &finds
 }
 
+// Precedence returns the file type signatures in the order their matchers are tried by [Find].
+//
+// Signatures with long or unique magic numbers are listed first, while the weaker signatures
+// that could otherwise cause false positives are listed last. For example, UTF32Text must go
+// before UTF16Text. The ANSIEscapeText and PlainText text heuristics are not included
+// as they are always checked after all the matchers.
+func Precedence() []Signature { //nolint:funlen
+	return []Signature{
+		PortableNetworkGraphics,
+		GraphicsInterchangeFormat,
+		JPEGFileInterchangeFormat,
+		JPEG2000,
+		AV1ImageFile,
+		GoogleWebP,
+		TaggedImageFileFormat,
+		InterleavedBitmap,
+		ElectronicArtsAnim,
+		PlanarBitMap,
+		ElectronicArtsIFF,
+		MPEG4,
+		QuickTimeM4V,
+		QuickTimeMovie,
+		MicrosoftAudioVideoInterleave,
+		MicrosoftWindowsMedia,
+		FlashVideo,
+		RealPlayer,
+		MPEG,
+		MusicalInstrumentDigitalInterface,
+		OggVorbisCodec,
+		FreeLosslessAudioCodec,
+		WaveAudioForWindows,
+		MPEG1AudioLayer3,
+		MPEGAdvancedAudioCoding,
+		MusicExtendedModule,
+		MusicMultiTrackModule,
+		MusicImpulseTracker,
+		PKWAREZip64,
+		PKWAREZipShrink,
+		PKWAREZipReduce,
+		PKWAREZipImplode,
+		PKWAREZip,
+		PKWAREMultiVolume,
+		RoshalARchivev5,
+		RoshalARchive,
+		X7zCompressArchive,
+		XZCompressArchive,
+		ZStandardArchive,
+		GzipCompressArchive,
+		Bzip2CompressArchive,
+		MicrosoftCABinet,
+		FreeArc,
+		ZooArchive,
+		ArchiveRobertJung,
+		YoshiLHA,
+		ACEArchive,
+		UltraCompressorII,
+		SqueezeItArchive,
+		LimitArchive,
+		CPMLibrary,
+		StuffIt5Archive,
+		StuffItArchive,
+		AppleSingle,
+		AppleDouble,
+		AmigaDMS,
+		AmigaLZX,
+		PowerPacker,
+		AmigaADF,
+		PKLITE,
+		PKSFX,
+		MicrosoftDOSKWAJ,
+		MicrosoftDOSSZDD,
+		MicrosoftCompoundFile,
+		MicrosoftExecutable,
+		CDPowerISO,
+		CDNero,
+		CDAlcohol120,
+		CDISO9660,
+		PortableDocumentFormat,
+		RichTextFormat,
+		WindowsHelpFile,
+		BinHex,
+		UTF32Text,
+		UTF8Text,
+		UTF16Text,
+		XBinaryText,
+		TapeARchive,
+		MusicProTracker,
+		NoGatePAK,
+		ARChiveSEA,
+		HyperArchive,
+		HarriHirvolaHA,
+		DeanCooperDWC,
+		CPMSqueeze,
+		CPMCrunch,
+		CPMCrunchLZH,
+		CompactProArchive,
+		MacBinary,
+		BMPFileFormat,
+		MicrosoftIcon,
+		PersonalComputereXchange,
+		RIPscrip,
+	}
+}
+
 // MatchExt determines if the reader matches the file type signature expected
 // from the extension of the filename. It returns true if the file type matches and
 // a found signature is always returned.
@@ -470,23 +687,34 @@ func New() *Finder { //nolint:funlen
 // and the PortableNetworkGraphics signature.
 // A PNG encoded image using the filename TEST.JPG will return false
 // and the PortableNetworkGraphics signature.
+//
+// Signatures without a matcher, such as PlainText and ANSIEscapeText, are compared
+// using the signature returned by [Find].
 func MatchExt(filename string, r io.ReaderAt) (bool, Signature, error) {
 	if Empty(r) {
 		return false, Unknown, ErrNilReader
 	}
 	ext := strings.ToLower(filepath.Ext(filename))
-	finds := New()
-	for signature, exts := range *Ext() {
-		if !slices.Contains(exts, ext) {
+	finds, exts := *New(), *Ext()
+	var zip pkClass
+	// the signatures are tried in the order of Find,
+	// so the same signature is returned when several match the extension
+	for _, signature := range Precedence() {
+		if !slices.Contains(exts[signature], ext) {
 			continue
 		}
-		for find, matcher := range *finds {
-			if matcher(r) && find == signature {
-				return true, find, nil
+		if found, ok := zip.match(r, signature); ok {
+			if found {
+				return true, signature, nil
 			}
+			continue
+		}
+		if matcher, exists := finds[signature]; exists && matcher(r) {
+			return true, signature, nil
 		}
 	}
-	return false, Find(r), nil
+	sign := Find(r)
+	return slices.Contains(exts[sign], ext), sign, nil
 }
 
 // Find returns the file type signature from the byte slice.
@@ -505,7 +733,19 @@ func FindW(w io.Writer, r io.ReaderAt) Signature {
 		return ZeroByte
 	}
 	matchers := *New()
-	for sign, matcher := range matchers {
+	var zip pkClass
+	for _, sign := range Precedence() {
+		if found, ok := zip.match(r, sign); ok {
+			if found {
+				fmt.Fprintf(w, name+" matchers sign: %s\n", sign)
+				return sign
+			}
+			continue
+		}
+		matcher, exists := matchers[sign]
+		if !exists {
+			continue
+		}
 		if matcher(r) {
 			fmt.Fprintf(w, name+" matchers sign: %s\n", sign)
 			return sign
@@ -187,6 +187,38 @@ func TestUnknowns(t *testing.T) {
 	be.Equal(t, magicnumber.ZeroByte, sign)
 }
 
+func TestMatchExt(t *testing.T) {
+	t.Parallel()
+	r, err := os.Open(uncompress(txtFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	b, sign, err := magicnumber.MatchExt(txtFile, r)
+	be.Err(t, err, nil)
+	be.True(t, b)
+	be.Equal(t, magicnumber.PlainText, sign)
+
+	r, err = os.Open(uncompress(ansiFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	b, sign, err = magicnumber.MatchExt(ansiFile, r)
+	be.Err(t, err, nil)
+	be.True(t, b)
+	be.Equal(t, magicnumber.ANSIEscapeText, sign)
+	b, sign, err = magicnumber.MatchExt(txtFile, r)
+	be.Err(t, err, nil)
+	be.True(t, !b)
+	be.Equal(t, magicnumber.ANSIEscapeText, sign)
+
+	szdd := strings.NewReader("SZDD\x88\xf0\x27\x33A\x00\x10\x00\x00\x00compressed")
+	b, sign, err = magicnumber.MatchExt("SETUP.EXE", szdd)
+	be.Err(t, err, nil)
+	be.True(t, !b)
+	be.Equal(t, magicnumber.MicrosoftDOSSZDD, sign)
+	b, _, err = magicnumber.MatchExt("SETUP.EX_", szdd)
+	be.Err(t, err, nil)
+	be.True(t, b)
+}
+
 func TestFind(t *testing.T) {
 	t.Parallel()
 	// walk the assets directory
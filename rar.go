@@ -0,0 +1,513 @@
+package magicnumber
+
+// Package file rar.go contains the functions that parse the archive and file headers
+// of the Roshal ARchive formats by Eugene Roshal, both the RAR 1.5 to 4.x format and the RAR 5 format.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+var ErrRarHeader = errors.New("rar header is invalid")
+
+// RarArchive is the archive header and the file headers of a RAR archive.
+type RarArchive struct {
+	Host             string     // Host is the operating system that created the archive, taken from the first file header
+	Entries          []RarEntry // Entries are the file entries of the archive, in the stored order
+	Format           int        // Format is 4 for the RAR 1.5 to 4.x format or 5 for the RAR 5 format
+	Solid            bool       // Solid is true if the files are compressed as a single stream
+	MultiVolume      bool       // MultiVolume is true if the archive is a part of a multi-volume set
+	FirstVolume      bool       // FirstVolume is true if the archive is the first part of a multi-volume set
+	Locked           bool       // Locked is true if the archive cannot be modified
+	Recovery         bool       // Recovery is true if the archive has a recovery record
+	EncryptedHeaders bool       // EncryptedHeaders is true if the headers are encrypted and the entries cannot be listed
+	Comment          string     // Comment is the stored archive comment, decoded from CP437 for RAR 4 or UTF-8 for RAR 5
+}
+
+// RarEntry is a file entry of a RAR archive.
+type RarEntry struct {
+	Name           string    // Name is the path of the file using the stored separators
+	Host           string    // Host is the operating system of the archiver
+	Modified       time.Time // Modified is the date and time the file was last modified
+	Offset         int64     // Offset is the position of the compressed data
+	CompressedSize int64     // CompressedSize is the size of the compressed data
+	OriginalSize   int64     // OriginalSize is the size of the file when uncompressed
+	CRC32          uint32    // CRC32 is the checksum of the uncompressed file
+	Method         RarMethod // Method is the compression method
+	Version        int       // Version is the version of RAR needed to extract multiplied by ten, such as 29 or 50
+	Dir            bool      // Dir is true if the entry is a directory
+	Encrypted      bool      // Encrypted is true if the file is encrypted with a password
+	Split          bool      // Split is true if the file continues from the previous or on the next volume
+}
+
+// RarMethod is the compression method of a RAR file entry.
+type RarMethod int
+
+// String returns the name of the compression method.
+func (m RarMethod) String() string {
+	if m < 0 || m > 5 {
+		return fmt.Sprintf("unknown method %d", int(m))
+	}
+	return [...]string{"store", "fastest", "fast", "normal", "good", "best"}[m]
+}
+
+// RarHeaders parses the archive header and the file headers of a RAR archive,
+// using either the RAR 4 or the RAR 5 format. The walk stops at the end of archive header,
+// the end of the file or at the first encrypted header.
+func RarHeaders(r io.ReaderAt) (RarArchive, error) {
+	if r == nil {
+		return RarArchive{}, ErrNilReader
+	}
+	var arc RarArchive
+	var err error
+	switch {
+	case Rar(r):
+		arc, err = rar4Headers(r)
+	case Rarv5(r):
+		arc, err = rar5Headers(r)
+	default:
+		return RarArchive{}, fmt.Errorf("%w: no marker", ErrRarHeader)
+	}
+	if len(arc.Entries) > 0 {
+		arc.Host = arc.Entries[0].Host
+	}
+	return arc, err
+}
+
+// rar4Headers walks the blocks of a RAR 1.5 to 4.x archive.
+func rar4Headers(r io.ReaderAt) (RarArchive, error) {
+	const (
+		mainHead    = 0x73
+		fileHead    = 0x74
+		commentHead = 0x75
+		protectHead = 0x78
+		newSubHead  = 0x7a
+		endHead     = 0x7b
+		longBlock   = 0x8000
+		headerLen   = 7
+	)
+	const (
+		mainVolume   = 0x0001
+		mainComment  = 0x0002
+		mainLock     = 0x0004
+		mainSolid    = 0x0008
+		mainRecovery = 0x0040
+		mainPassword = 0x0080
+		mainFirst    = 0x0100
+	)
+	arc := RarArchive{Format: 4, Entries: []RarEntry{}}
+	le := binary.LittleEndian
+	offset := int64(len("Rar!\x1a\x07\x00"))
+	for range maxBlocks {
+		p := make([]byte, headerLen)
+		if n, _ := r.ReadAt(p, offset); n == 0 {
+			// some archivers do not write the end of archive block
+			return arc, nil
+		} else if n < headerLen {
+			return arc, fmt.Errorf("%w: short block at %d", ErrRarHeader, offset)
+		}
+		blockType := p[2]
+		flags := le.Uint16(p[3:])
+		size := int64(le.Uint16(p[5:]))
+		if size < headerLen {
+			return arc, fmt.Errorf("%w: block size %d at %d", ErrRarHeader, size, offset)
+		}
+		head := make([]byte, size)
+		if _, err := r.ReadAt(head, offset); err != nil {
+			return arc, fmt.Errorf("%w: block at %d", ErrRarHeader, offset)
+		}
+		switch blockType {
+		case mainHead:
+			arc.MultiVolume = flags&mainVolume != 0
+			arc.FirstVolume = flags&mainFirst != 0
+			arc.Locked = flags&mainLock != 0
+			arc.Solid = flags&mainSolid != 0
+			arc.Recovery = flags&mainRecovery != 0
+			// the RAR 2 comment block follows the 13 byte archive header
+			const mainLen, commentLen = 13, 13
+			if sub := head[min(mainLen, len(head)):]; flags&mainComment != 0 && len(sub) > commentLen &&
+				sub[2] == commentHead && sub[10] == rar4Stored {
+				end := min(int(le.Uint16(sub[5:])), len(sub))
+				arc.Comment = DecodeCP437(sub[min(commentLen, end):end])
+			}
+			if flags&mainPassword != 0 {
+				arc.EncryptedHeaders = true
+				return arc, nil
+			}
+		case fileHead:
+			entry, err := rar4File(head, flags)
+			if err != nil {
+				return arc, fmt.Errorf("%w at %d", err, offset)
+			}
+			entry.Offset = offset + size
+			arc.Entries = append(arc.Entries, entry)
+		case protectHead:
+			arc.Recovery = true
+		case newSubHead:
+			const nameSize, method, highSize = 26, 25, 0x0100
+			nameStart := 32
+			if flags&highSize != 0 {
+				nameStart += 8
+			}
+			if len(head) < nameStart {
+				break
+			}
+			switch name := head[nameStart:min(nameStart+int(le.Uint16(head[nameSize:])), len(head))]; {
+			case string(name) == "RR":
+				arc.Recovery = true
+			case string(name) == "CMT" && head[method] == rar4Stored:
+				// the RAR 3 comment is the data of the CMT sub-block
+				arc.Comment = DecodeCP437(rarData(r, offset+size, int64(le.Uint32(head[headerLen:]))))
+			}
+		case endHead:
+			return arc, nil
+		}
+		if (flags&longBlock != 0 || blockType == fileHead) && len(head) >= headerLen+4 {
+			size += int64(le.Uint32(head[headerLen:]))
+		}
+		offset += size
+	}
+	return arc, fmt.Errorf("%w: too many blocks", ErrRarHeader)
+}
+
+// rar4Stored is the store method of the RAR 4 file and sub-block headers.
+const rar4Stored = 0x30
+
+// rarData returns the data of a stored comment, which is empty when the size
+// is larger than 2 MiB or the data cannot be read.
+func rarData(r io.ReaderAt, offset, size int64) []byte {
+	const maxComment = 2 << 20
+	if size <= 0 || size > maxComment {
+		return nil
+	}
+	p := make([]byte, size)
+	if _, err := r.ReadAt(p, offset); err != nil {
+		return nil
+	}
+	return p
+}
+
+// rar4File parses a RAR 4 file header block.
+func rar4File(head []byte, flags uint16) (RarEntry, error) {
+	const (
+		splitBefore = 0x0001
+		splitAfter  = 0x0002
+		password    = 0x0004
+		dictionary  = 0x00e0
+		directory   = 0x00e0
+		large       = 0x0100
+		unicode     = 0x0200
+		nameStart   = 32
+	)
+	if len(head) < nameStart {
+		return RarEntry{}, fmt.Errorf("%w: short file header", ErrRarHeader)
+	}
+	le := binary.LittleEndian
+	stamp := le.Uint32(head[20:])
+	entry := RarEntry{
+		Host:           rar4Host(head[15]),
+		Modified:       DosTime(uint16(stamp>>16), uint16(stamp)),
+		CompressedSize: int64(le.Uint32(head[7:])),
+		OriginalSize:   int64(le.Uint32(head[11:])),
+		CRC32:          le.Uint32(head[16:]),
+		Version:        int(head[24]),
+		Method:         RarMethod(int(head[25]) - rar4Stored),
+		Dir:            flags&dictionary == directory,
+		Encrypted:      flags&password != 0,
+		Split:          flags&(splitBefore|splitAfter) != 0,
+	}
+	start := nameStart
+	if flags&large != 0 {
+		start += 8
+		if len(head) < start {
+			return RarEntry{}, fmt.Errorf("%w: short file header", ErrRarHeader)
+		}
+		entry.CompressedSize |= int64(le.Uint32(head[32:])) << 32
+		entry.OriginalSize |= int64(le.Uint32(head[36:])) << 32
+	}
+	end := start + int(le.Uint16(head[26:]))
+	if end > len(head) {
+		return RarEntry{}, fmt.Errorf("%w: filename length", ErrRarHeader)
+	}
+	name := head[start:end]
+	entry.Name = DecodeCP437(name)
+	if flags&unicode != 0 {
+		if ascii, enc, found := bytes.Cut(name, []byte{0}); found {
+			entry.Name = rarUnicode(ascii, enc)
+		} else {
+			entry.Name = string(name)
+		}
+	}
+	return entry, nil
+}
+
+// rarUnicode decodes the compressed UTF-16 filename of a RAR 4 file header,
+// where the name is the ASCII filename and the enc is the encoded data that follows it.
+func rarUnicode(name, enc []byte) string {
+	if len(enc) == 0 {
+		return DecodeCP437(name)
+	}
+	high := uint16(enc[0]) << 8
+	out := make([]uint16, 0, len(name))
+	i := 1
+	var flags byte
+	bits := 0
+	for i < len(enc) {
+		if bits == 0 {
+			flags, bits = enc[i], 8
+			i++
+			continue
+		}
+		switch flags >> 6 {
+		case 0:
+			out = append(out, uint16(enc[i]))
+			i++
+		case 1:
+			out = append(out, uint16(enc[i])|high)
+			i++
+		case 2:
+			if i+1 >= len(enc) {
+				return string(utf16.Decode(out))
+			}
+			out = append(out, uint16(enc[i])|uint16(enc[i+1])<<8)
+			i += 2
+		case 3:
+			length := int(enc[i])
+			i++
+			if length&0x80 == 0 {
+				for range length + 2 {
+					if len(out) >= len(name) {
+						break
+					}
+					out = append(out, uint16(name[len(out)]))
+				}
+				break
+			}
+			if i >= len(enc) {
+				return string(utf16.Decode(out))
+			}
+			correction := enc[i]
+			i++
+			for range length&0x7f + 2 {
+				if len(out) >= len(name) {
+					break
+				}
+				out = append(out, uint16(name[len(out)]+correction)|high)
+			}
+		}
+		flags <<= 2
+		bits -= 2
+	}
+	return string(utf16.Decode(out))
+}
+
+// rar4Host returns the name of the host operating system of a RAR 4 file header.
+func rar4Host(host byte) string {
+	hosts := [...]string{"MS-DOS", "OS/2", "Windows", "Unix", "Mac OS", "BeOS"}
+	if int(host) < len(hosts) {
+		return hosts[host]
+	}
+	return fmt.Sprintf("unknown host %d", host)
+}
+
+// rar5Headers walks the headers of a RAR 5 archive.
+func rar5Headers(r io.ReaderAt) (RarArchive, error) {
+	const (
+		mainHead       = 1
+		fileHead       = 2
+		serviceHead    = 3
+		encryptionHead = 4
+		endHead        = 5
+		extraArea      = 0x1
+		dataArea       = 0x2
+		splitBefore    = 0x8
+		splitAfter     = 0x10
+		maxHeader      = 2 << 20
+	)
+	const (
+		mainVolume   = 0x1
+		mainNumber   = 0x2
+		mainSolid    = 0x4
+		mainRecovery = 0x8
+		mainLock     = 0x10
+	)
+	arc := RarArchive{Format: 5, Entries: []RarEntry{}}
+	offset := int64(len("Rar!\x1a\x07\x01\x00"))
+	for range maxBlocks {
+		// the crc32 is followed by the header size vint
+		p := make([]byte, 4+binary.MaxVarintLen32)
+		n, _ := r.ReadAt(p, offset)
+		if n == 0 {
+			return arc, nil
+		}
+		size, vn := binary.Uvarint(p[4:n])
+		if vn <= 0 || size == 0 || size > maxHeader {
+			return arc, fmt.Errorf("%w: header size at %d", ErrRarHeader, offset)
+		}
+		head := make([]byte, size)
+		if _, err := r.ReadAt(head, offset+4+int64(vn)); err != nil {
+			return arc, fmt.Errorf("%w: header at %d", ErrRarHeader, offset)
+		}
+		fields, rest := rar5Fields(head, 2)
+		if len(fields) < 2 {
+			return arc, fmt.Errorf("%w: header at %d", ErrRarHeader, offset)
+		}
+		headType, flags := fields[0], fields[1]
+		var extraSize, dataSize uint64
+		if flags&extraArea != 0 {
+			fields, rest = rar5Fields(rest, 1)
+			if len(fields) == 1 {
+				extraSize = fields[0]
+			}
+		}
+		if flags&dataArea != 0 {
+			fields, rest = rar5Fields(rest, 1)
+			if len(fields) == 1 {
+				dataSize = fields[0]
+			}
+		}
+		if extraSize > uint64(len(rest)) {
+			return arc, fmt.Errorf("%w: extra area size at %d", ErrRarHeader, offset)
+		}
+		extra := head[size-extraSize:]
+		dataOffset := offset + 4 + int64(vn) + int64(size)
+		switch headType {
+		case mainHead:
+			fields, _ = rar5Fields(rest, 2)
+			if len(fields) > 0 {
+				archive := fields[0]
+				arc.MultiVolume = archive&mainVolume != 0
+				arc.FirstVolume = arc.MultiVolume &&
+					(archive&mainNumber == 0 || len(fields) > 1 && fields[1] == 0)
+				arc.Solid = archive&mainSolid != 0
+				arc.Recovery = archive&mainRecovery != 0
+				arc.Locked = archive&mainLock != 0
+			}
+		case fileHead, serviceHead:
+			entry, err := rar5File(rest)
+			if err != nil {
+				return arc, fmt.Errorf("%w at %d", err, offset)
+			}
+			if headType == serviceHead {
+				switch {
+				case entry.Name == "RR":
+					arc.Recovery = true
+				case entry.Name == "CMT" && entry.Method == 0:
+					arc.Comment = strings.TrimRight(string(rarData(r, dataOffset, int64(dataSize))), "\x00")
+				}
+				break
+			}
+			const encryptRecord = 0x1
+			entry.Encrypted = rar5Record(extra, encryptRecord)
+			entry.Split = flags&(splitBefore|splitAfter) != 0
+			entry.Offset = dataOffset
+			entry.CompressedSize = int64(dataSize)
+			arc.Entries = append(arc.Entries, entry)
+		case encryptionHead:
+			arc.EncryptedHeaders = true
+			return arc, nil
+		case endHead:
+			return arc, nil
+		}
+		offset = dataOffset + int64(dataSize)
+	}
+	return arc, fmt.Errorf("%w: too many headers", ErrRarHeader)
+}
+
+// rar5Fields returns up to count variable length integers from the start of p and the remaining bytes.
+func rar5Fields(p []byte, count int) ([]uint64, []byte) {
+	vals := make([]uint64, 0, count)
+	for range count {
+		v, n := binary.Uvarint(p)
+		if n <= 0 {
+			break
+		}
+		vals = append(vals, v)
+		p = p[n:]
+	}
+	return vals, p
+}
+
+// rar5Vints returns up to count variable length integers from the start of p.
+func rar5Vints(p []byte, count int) []uint64 {
+	vals, _ := rar5Fields(p, count)
+	return vals
+}
+
+// rar5Record returns true if the RAR v5 extra area contains a record of the type.
+func rar5Record(extra []byte, recordType uint64) bool {
+	for len(extra) > 0 {
+		size, n := binary.Uvarint(extra)
+		if n <= 0 || size == 0 || uint64(len(extra)-n) < size {
+			return false
+		}
+		if vals := rar5Vints(extra[n:], 1); len(vals) == 1 && vals[0] == recordType {
+			return true
+		}
+		extra = extra[n+int(size):]
+	}
+	return false
+}
+
+// rar5File parses the type specific fields of a RAR 5 file or service header.
+func rar5File(p []byte) (RarEntry, error) {
+	const (
+		directory = 0x1
+		hasTime   = 0x2
+		hasCRC    = 0x4
+	)
+	var entry RarEntry
+	fields, p := rar5Fields(p, 3)
+	if len(fields) < 3 {
+		return entry, fmt.Errorf("%w: short file header", ErrRarHeader)
+	}
+	fileFlags := fields[0]
+	entry.OriginalSize = int64(fields[1])
+	entry.Dir = fileFlags&directory != 0
+	le := binary.LittleEndian
+	if fileFlags&hasTime != 0 {
+		if len(p) < 4 {
+			return entry, fmt.Errorf("%w: short file header", ErrRarHeader)
+		}
+		entry.Modified = time.Unix(int64(le.Uint32(p)), 0).UTC()
+		p = p[4:]
+	}
+	if fileFlags&hasCRC != 0 {
+		if len(p) < 4 {
+			return entry, fmt.Errorf("%w: short file header", ErrRarHeader)
+		}
+		entry.CRC32 = le.Uint32(p)
+		p = p[4:]
+	}
+	fields, p = rar5Fields(p, 3)
+	if len(fields) < 3 || fields[2] > uint64(len(p)) {
+		return entry, fmt.Errorf("%w: filename length", ErrRarHeader)
+	}
+	const rar5, rar7 = 50, 70
+	compression := fields[0]
+	entry.Version = rar5
+	if compression&0x3f == 1 {
+		entry.Version = rar7
+	}
+	entry.Method = RarMethod(compression >> 7 & 0x7)
+	entry.Host = rar5Host(fields[1])
+	entry.Name = string(p[:fields[2]])
+	return entry, nil
+}
+
+// rar5Host returns the name of the host operating system of a RAR 5 file header.
+func rar5Host(host uint64) string {
+	switch host {
+	case 0:
+		return "Windows"
+	case 1:
+		return "Unix"
+	}
+	return fmt.Sprintf("unknown host %d", host)
+}
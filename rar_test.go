@@ -0,0 +1,121 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestRarHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestRarHeaders")
+	_, err := magicnumber.RarHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	_, err = magicnumber.RarHeaders(bytes.NewReader([]byte("not a rar archive")))
+	be.Err(t, err, magicnumber.ErrRarHeader)
+
+	r, err := os.Open(tdfile(rarFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	arc, err := magicnumber.RarHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, 4, arc.Format)
+	be.Equal(t, "MS-DOS", arc.Host)
+	be.True(t, !arc.Solid && !arc.MultiVolume && !arc.Locked && !arc.Recovery && !arc.EncryptedHeaders)
+	be.Equal(t, 4, len(arc.Entries))
+	e := arc.Entries[0]
+	be.Equal(t, "BLOOD3D.RAR", e.Name)
+	be.Equal(t, "store", e.Method.String())
+	be.Equal(t, 20, e.Version)
+	be.Equal(t, int64(63), e.Offset)
+	be.Equal(t, int64(2061), e.CompressedSize)
+	be.Equal(t, int64(2061), e.OriginalSize)
+	be.Equal(t, time.Date(1997, time.June, 21, 16, 10, 48, 0, time.UTC), e.Modified)
+	be.Equal(t, "MDKTRAIN.RAR", arc.Entries[3].Name)
+
+	r5, err := os.Open(tdfile(rarv5File))
+	be.Err(t, err, nil)
+	defer r5.Close()
+	arc, err = magicnumber.RarHeaders(r5)
+	be.Err(t, err, nil)
+	be.Equal(t, 5, arc.Format)
+	be.Equal(t, "Unix", arc.Host)
+	be.Equal(t, 15, len(arc.Entries))
+	e = arc.Entries[0]
+	be.Equal(t, "TEST.ANS", e.Name)
+	be.Equal(t, magicnumber.RarMethod(0), e.Method)
+	be.Equal(t, 50, e.Version)
+	be.Equal(t, int64(60), e.Offset)
+	be.Equal(t, int64(68), e.CompressedSize)
+	be.Equal(t, time.Date(2012, time.September, 19, 4, 21, 52, 0, time.UTC), e.Modified)
+	p := make([]byte, 4)
+	_, err = r5.ReadAt(p, e.Offset)
+	be.Err(t, err, nil)
+	be.Equal(t, "\x1b[1m", string(p))
+	bmp := arc.Entries[2]
+	be.Equal(t, "TEST.BMP", bmp.Name)
+	be.Equal(t, "normal", bmp.Method.String())
+	be.Equal(t, int64(1522), bmp.CompressedSize)
+	be.Equal(t, int64(750054), bmp.OriginalSize)
+}
+
+func TestRarFlags(t *testing.T) {
+	t.Parallel()
+	t.Log("TestRarFlags")
+	rar, err := os.ReadFile(tdfile(rarFile))
+	be.Err(t, err, nil)
+	// set the volume, lock, solid, recovery and first volume flags of the archive header
+	const mainFlags = 7 + 3
+	binary.LittleEndian.PutUint16(rar[mainFlags:], 0x0001|0x0004|0x0008|0x0040|0x0100)
+	arc, err := magicnumber.RarHeaders(bytes.NewReader(rar))
+	be.Err(t, err, nil)
+	be.True(t, arc.MultiVolume && arc.FirstVolume && arc.Locked && arc.Solid && arc.Recovery)
+	be.True(t, !arc.EncryptedHeaders)
+	binary.LittleEndian.PutUint16(rar[mainFlags:], 0x0080)
+	arc, err = magicnumber.RarHeaders(bytes.NewReader(rar))
+	be.Err(t, err, nil)
+	be.True(t, arc.EncryptedHeaders)
+	be.Equal(t, 0, len(arc.Entries))
+
+	rar5, err := os.ReadFile(tdfile(rarv5File))
+	be.Err(t, err, nil)
+	// set the volume, solid, recovery and locked flags of the main archive header
+	const archiveFlags = 0x10
+	rar5[archiveFlags] = 0x1 | 0x4 | 0x8 | 0x10
+	arc, err = magicnumber.RarHeaders(bytes.NewReader(rar5))
+	be.Err(t, err, nil)
+	be.True(t, arc.MultiVolume && arc.FirstVolume && arc.Solid && arc.Recovery && arc.Locked)
+}
+
+func TestRarUnicode(t *testing.T) {
+	t.Parallel()
+	t.Log("TestRarUnicode")
+	rar := []byte("Rar!\x1a\x07\x00")
+	rar = append(rar, 0, 0, 0x73, 0, 0, 13, 0, 0, 0, 0, 0, 0, 0)
+	// the encoded name replaces the first character with U+0416 and copies the other four
+	name := append([]byte("_.txt\x00"), 0x04, 0x70, 0x16, 0x02)
+	head := make([]byte, 32)
+	head[2] = 0x74
+	binary.LittleEndian.PutUint16(head[3:], 0x8000|0x0200)
+	binary.LittleEndian.PutUint16(head[5:], uint16(len(head)+len(name)))
+	binary.LittleEndian.PutUint32(head[7:], 3)
+	binary.LittleEndian.PutUint32(head[11:], 3)
+	head[15], head[24], head[25] = 2, 29, 0x33
+	binary.LittleEndian.PutUint16(head[26:], uint16(len(name)))
+	rar = append(rar, head...)
+	rar = append(rar, name...)
+	rar = append(rar, "abc"...)
+	rar = append(rar, 0, 0, 0x7b, 0, 0x40, 7, 0)
+	arc, err := magicnumber.RarHeaders(bytes.NewReader(rar))
+	be.Err(t, err, nil)
+	be.Equal(t, 1, len(arc.Entries))
+	be.Equal(t, "Ж.txt", arc.Entries[0].Name)
+	be.Equal(t, "Windows", arc.Host)
+	be.Equal(t, "normal", arc.Entries[0].Method.String())
+	be.Equal(t, 29, arc.Entries[0].Version)
+}
@@ -0,0 +1,217 @@
+package magicnumber
+
+// Package file sfx.go contains the functions that find the archive that is embedded in a
+// self-extracting archive, which is a DOS or Windows program stub that is followed by the archive.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var ErrSFX = errors.New("executable has no embedded archive")
+
+// SFXStub is the self-extractor program that is the executable stub of a self-extracting archive.
+type SFXStub int
+
+const (
+	UnknownSFX   SFXStub = iota // UnknownSFX is an unknown self-extractor
+	PKWARESFX                   // PKWARESFX is the PKSFX self-extractor of PKZIP
+	ZipSFX                      // ZipSFX is a ZIP self-extractor, such as the Info-ZIP UnZipSFX
+	WinZipSFX                   // WinZipSFX is the WinZip Self-Extractor
+	LHASFX                      // LHASFX is the self-extractor of LHarc and LHA
+	ARJSFX                      // ARJSFX is the self-extractor of ARJ
+	ARJJuniorSFX                // ARJJuniorSFX is the smaller ARJSFXJR self-extractor of ARJ
+	RARSFX                      // RARSFX is the self-extractor of RAR and WinRAR
+	ACESFX                      // ACESFX is the self-extractor of ACE and WinACE
+	X7zSFX                      // X7zSFX is the self-extractor of 7-Zip
+)
+
+func (s SFXStub) String() string {
+	if s < UnknownSFX || s > X7zSFX {
+		return ""
+	}
+	return [...]string{
+		"unknown self-extractor",
+		"PKSFX",
+		"ZIP SFX",
+		"WinZip Self-Extractor",
+		"LHA SFX",
+		"ARJ SFX",
+		"ARJSFXJR",
+		"RAR SFX",
+		"ACE SFX",
+		"7-Zip SFX",
+	}[s]
+}
+
+// SFX is the self-extractor and the archive that is embedded in a self-extracting archive.
+type SFX struct {
+	Stub      SFXStub   // Stub is the self-extractor program
+	Signature Signature // Signature is the format of the embedded archive
+	Offset    int64     // Offset is the position of the embedded archive
+}
+
+// Section returns a reader of the embedded archive, which can be passed to [Find]
+// or to the header parsers of the archive format.
+func (s SFX) Section(r io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(r, s.Offset, sfxSize(r, s.Offset))
+}
+
+const (
+	sfxScan    = 256 * 1024  // sfxScan is the distance after the executable image that is searched for the archive
+	sfxMaxStub = 1024 * 1024 // sfxMaxStub is the size of the stub that is searched for the self-extractor name
+)
+
+// SelfExtractor finds the archive that is embedded in a self-extracting DOS or Windows executable
+// and returns the self-extractor, the signature of the archive and the offset of the archive.
+//
+// Self-extractors append the archive to a copy of their program, so the archive is searched for
+// after the end of the MZ image, or after the last section of a Portable Executable.
+// The LHA, ARJ, RAR, ACE, ZIP and 7z archive formats are supported.
+// An executable without an embedded archive returns the ErrSFX error.
+func SelfExtractor(r io.ReaderAt) (SFX, error) {
+	if r == nil {
+		return SFX{}, ErrNilReader
+	}
+	if !MSExe(r) {
+		return SFX{}, fmt.Errorf("%w: not an executable", ErrSFX)
+	}
+	start := sfxImage(r)
+	if start <= 0 {
+		return SFX{}, fmt.Errorf("%w: no executable image", ErrSFX)
+	}
+	p := make([]byte, sfxScan)
+	n, _ := r.ReadAt(p, start)
+	p = p[:n]
+	for i := range p {
+		if !sfxMagic(p[i:]) {
+			continue
+		}
+		offset := start + int64(i)
+		sign, _ := Archive(io.NewSectionReader(r, offset, sfxSize(r, offset)))
+		stub := sfxStub(sign)
+		if stub == UnknownSFX {
+			continue
+		}
+		stub = sfxVariant(r, stub, start)
+		return SFX{Stub: stub, Signature: sign, Offset: offset}, nil
+	}
+	return SFX{}, fmt.Errorf("%w: after the image at %d", ErrSFX, start)
+}
+
+// sfxMagic returns true if the bytes start with the signature of an archive format that is
+// used by the self-extractors, which is confirmed by the archive matchers.
+func sfxMagic(p []byte) bool {
+	const lhaMethod, aceMagic = 2, 7
+	switch {
+	case bytes.HasPrefix(p, []byte("PK\x03\x04")),
+		bytes.HasPrefix(p, []byte("Rar!\x1a\x07")),
+		bytes.HasPrefix(p, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}),
+		bytes.HasPrefix(p, []byte{0x60, 0xea}):
+		return true
+	case len(p) > lhaMethod+1 && p[lhaMethod] == '-' && (p[lhaMethod+1] == 'l' || p[lhaMethod+1] == 'p'):
+		return true
+	case len(p) > aceMagic && bytes.HasPrefix(p[aceMagic:], []byte("**ACE**")):
+		return true
+	}
+	return false
+}
+
+// sfxStub returns the self-extractor of the embedded archive format,
+// or UnknownSFX if the format is not used by the self-extractors.
+func sfxStub(sign Signature) SFXStub {
+	switch sign {
+	case PKWAREZipShrink, PKWAREZipReduce, PKWAREZipImplode, PKWAREZip64, PKWAREZip:
+		return ZipSFX
+	case YoshiLHA:
+		return LHASFX
+	case ArchiveRobertJung:
+		return ARJSFX
+	case RoshalARchive, RoshalARchivev5:
+		return RARSFX
+	case ACEArchive:
+		return ACESFX
+	case X7zCompressArchive:
+		return X7zSFX
+	}
+	return UnknownSFX
+}
+
+// sfxVariant returns the ZIP and ARJ self-extractor that is named in the executable stub.
+func sfxVariant(r io.ReaderAt, stub SFXStub, end int64) SFXStub {
+	if stub != ZipSFX && stub != ARJSFX {
+		return stub
+	}
+	p := make([]byte, min(end, sfxMaxStub))
+	n, _ := r.ReadAt(p, 0)
+	p = p[:n]
+	switch {
+	case stub == ZipSFX && (Pksfx(r) || bytes.Contains(p, []byte("PKSFX"))):
+		return PKWARESFX
+	case stub == ZipSFX && bytes.Contains(p, []byte("WinZip")):
+		return WinZipSFX
+	case stub == ARJSFX && bytes.Contains(p, []byte("ARJSFXJR")):
+		return ARJJuniorSFX
+	}
+	return stub
+}
+
+// sfxImage returns the end of the executable image, which is the end of the last section of a
+// Portable Executable, otherwise it is the size of the program that is stored in the MZ header.
+func sfxImage(r io.ReaderAt) int64 {
+	const size = 64
+	p := make([]byte, size)
+	if _, err := r.ReadAt(p, 0); err != nil {
+		return 0
+	}
+	le := binary.LittleEndian
+	const peHeaderIndex = 0x3c
+	if end := sfxSections(r, int64(le.Uint32(p[peHeaderIndex:]))); end > 0 {
+		return end
+	}
+	// the image size is the number of 512 byte pages,
+	// with the bytes used by the last page when it is not full
+	const page = 512
+	last, pages := int64(le.Uint16(p[2:])), int64(le.Uint16(p[4:]))
+	end := pages * page
+	if last > 0 && last < page {
+		end -= page - last
+	}
+	return end
+}
+
+// sfxSections returns the end of the last section of the Portable Executable at the offset,
+// or 0 when there is no Portable Executable header.
+func sfxSections(r io.ReaderAt, offset int64) int64 {
+	const coffLen, sectionLen = 24, 40
+	head := make([]byte, coffLen)
+	if _, err := r.ReadAt(head, offset); err != nil || !bytes.HasPrefix(head, []byte("PE\x00\x00")) {
+		return 0
+	}
+	le := binary.LittleEndian
+	sections, optional := int(le.Uint16(head[6:])), int64(le.Uint16(head[20:]))
+	table := make([]byte, sections*sectionLen)
+	if _, err := r.ReadAt(table, offset+coffLen+optional); err != nil {
+		return 0
+	}
+	var end int64
+	for i := range sections {
+		section := table[i*sectionLen:]
+		size, pointer := int64(le.Uint32(section[16:])), int64(le.Uint32(section[20:]))
+		end = max(end, pointer+size)
+	}
+	return end
+}
+
+// sfxSize returns the size of the embedded archive at the offset,
+// which is unlimited when the size of the reader is unknown.
+func sfxSize(r io.ReaderAt, offset int64) int64 {
+	if length := Length(r); length > offset {
+		return length - offset
+	}
+	return math.MaxInt64 - offset
+}
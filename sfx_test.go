@@ -0,0 +1,110 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+// dosStub returns a DOS executable with a 1124 byte image that contains the text.
+func dosStub(text string) []byte {
+	const image, page = 1124, 512
+	b := make([]byte, image)
+	copy(b, "MZ")
+	binary.LittleEndian.PutUint16(b[2:], image%page)
+	binary.LittleEndian.PutUint16(b[4:], image/page+1)
+	binary.LittleEndian.PutUint16(b[8:], 4)
+	copy(b[64:], text)
+	return b
+}
+
+// winStub returns a Portable Executable with a single section that ends at 1024 bytes and contains the text.
+func winStub(text string) []byte {
+	const peHeader, optional, section = 0x80, 0xe0, 0x200
+	le := binary.LittleEndian
+	b := make([]byte, 2*section)
+	copy(b, "MZ")
+	le.PutUint16(b[2:], 0x90)
+	le.PutUint16(b[4:], 1)
+	le.PutUint32(b[0x3c:], peHeader)
+	copy(b[peHeader:], "PE\x00\x00")
+	le.PutUint16(b[peHeader+4:], 0x14c)
+	le.PutUint16(b[peHeader+6:], 1)
+	le.PutUint16(b[peHeader+20:], optional)
+	table := b[peHeader+24+optional:]
+	copy(table, ".rsrc")
+	le.PutUint32(table[16:], section)
+	le.PutUint32(table[20:], section)
+	copy(b[section:], text)
+	return b
+}
+
+func TestSelfExtractor(t *testing.T) {
+	t.Parallel()
+	t.Log("TestSelfExtractor")
+	_, err := magicnumber.SelfExtractor(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	read := func(name string) []byte {
+		b, err := os.ReadFile(tdfile(name))
+		be.Err(t, err, nil)
+		return b
+	}
+	arj, lha := read("ARJ310.ARJ"), read("LHA114.LZH")
+	rar, x7z, zip := read("RAR624.RAR"), read("TEST.7z"), read("PKZ204EX.ZIP")
+	ace := bbsArchives()[0].b
+	// the section padding of a Windows executable is before the archive
+	padding := make([]byte, 16)
+	tests := []struct {
+		name   string
+		stub   []byte
+		b      []byte
+		want   magicnumber.SFXStub
+		sign   magicnumber.Signature
+		offset int64
+	}{
+		{"LHA", dosStub("LHA's SFX 2.13S (c) Yoshi"), lha, magicnumber.LHASFX, magicnumber.YoshiLHA, 1124},
+		{"ARJ", dosStub("ARJSFX 3.10 Copyright (c) ARJ Software"), arj, magicnumber.ARJSFX, magicnumber.ArchiveRobertJung, 1124},
+		{"ARJSFXJR", dosStub("ARJSFXJR 3.10 Copyright (c) ARJ Software"), arj, magicnumber.ARJJuniorSFX, magicnumber.ArchiveRobertJung, 1124},
+		{"PKSFX", dosStub("PKSFX (R) FAST! Self Extract Utility"), zip, magicnumber.PKWARESFX, magicnumber.PKWAREZip, 1124},
+		{"ZIP", dosStub("UnZipSFX 5.52"), zip, magicnumber.ZipSFX, magicnumber.PKWAREZip, 1124},
+		{"RAR", winStub("WinRAR self-extracting archive"), rar, magicnumber.RARSFX, magicnumber.RoshalARchivev5, 1024},
+		{"ACE", winStub("WinACE self-extractor"), ace, magicnumber.ACESFX, magicnumber.ACEArchive, 1024},
+		{"WinZip", winStub("WinZip Self-Extractor"), append(padding, zip...), magicnumber.WinZipSFX, magicnumber.PKWAREZip, 1040},
+		{"7-Zip", winStub("7-Zip SFX"), append(padding, x7z...), magicnumber.X7zSFX, magicnumber.X7zCompressArchive, 1040},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := bytes.NewReader(append(bytes.Clone(tt.stub), tt.b...))
+			sfx, err := magicnumber.SelfExtractor(r)
+			be.Err(t, err, nil)
+			be.Equal(t, tt.want, sfx.Stub)
+			be.Equal(t, tt.sign, sfx.Signature)
+			be.Equal(t, tt.offset, sfx.Offset)
+			be.Equal(t, tt.sign, magicnumber.Find(sfx.Section(r)))
+		})
+	}
+	// an Actually Portable Executable stores its files in a ZIP archive after the image
+	f, err := os.Open(tdfile("binaries/windows/hellojs.com"))
+	be.Err(t, err, nil)
+	defer f.Close()
+	sfx, err := magicnumber.SelfExtractor(f)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.ZipSFX, sfx.Stub)
+	be.Equal(t, magicnumber.PKWAREZip, sfx.Signature)
+	be.Equal(t, int64(294912), sfx.Offset)
+	be.Equal(t, "ARJSFXJR", magicnumber.ARJJuniorSFX.String())
+	be.Equal(t, "7-Zip SFX", magicnumber.X7zSFX.String())
+	// an executable without an archive and an archive without an executable
+	_, err = magicnumber.SelfExtractor(bytes.NewReader(winStub("PK\x03\x04 -lh5- not an archive")))
+	be.Err(t, err, magicnumber.ErrSFX)
+	_, err = magicnumber.SelfExtractor(bytes.NewReader(append(dosStub("program"), "greetings"...)))
+	be.Err(t, err, magicnumber.ErrSFX)
+	_, err = magicnumber.SelfExtractor(strings.NewReader("not an executable"))
+	be.Err(t, err, magicnumber.ErrSFX)
+}
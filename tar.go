@@ -0,0 +1,305 @@
+package magicnumber
+
+// Package file tar.go contains the functions that parse the headers of the Tape ARchive format,
+// including the pre-POSIX V7, the POSIX ustar and pax, and the GNU variants.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrTarHeader = errors.New("tar header is invalid")
+
+// TarFormat is the variant of the tar format.
+type TarFormat int
+
+const (
+	TarV7    TarFormat = iota // TarV7 is the pre-POSIX format of Unix V7 without a magic value
+	TarUstar                  // TarUstar is the POSIX.1-1988 ustar format
+	TarGNU                    // TarGNU is the GNU tar format with the long name and long link entries
+	TarPAX                    // TarPAX is the POSIX.1-2001 pax format with the extended header records
+)
+
+// String returns the name of the tar format.
+func (f TarFormat) String() string {
+	if f < TarV7 || f > TarPAX {
+		return ""
+	}
+	return [...]string{"V7", "ustar", "GNU", "pax"}[f]
+}
+
+// TarArchive is the format and the entries of a tar archive.
+type TarArchive struct {
+	Entries []TarEntry // Entries are the file entries of the archive, in the stored order
+	Format  TarFormat  // Format is the variant of the tar format
+}
+
+// TarEntry is a file entry of a tar archive.
+type TarEntry struct {
+	Name     string            // Name is the path of the file, which includes a GNU long name or a pax path
+	Linkname string            // Linkname is the target of a hard or a symbolic link
+	Uname    string            // Uname is the user name of the owner
+	Gname    string            // Gname is the group name of the owner
+	Records  map[string]string // Records are the pax extended header records of the entry
+	Modified time.Time         // Modified is the date and time the file was last modified
+	Offset   int64             // Offset is the position of the file data
+	Size     int64             // Size is the size of the file data
+	Mode     int64             // Mode is the permission and mode bits
+	Type     TarType           // Type is the kind of entry, such as a regular file or a directory
+}
+
+// TarType is the typeflag of a tar entry.
+type TarType byte
+
+// String returns the name of the typeflag.
+func (t TarType) String() string {
+	switch t {
+	case 0, '0', '7':
+		return "file"
+	case '1':
+		return "hard link"
+	case '2':
+		return "symbolic link"
+	case '3':
+		return "character device"
+	case '4':
+		return "block device"
+	case '5':
+		return "directory"
+	case '6':
+		return "fifo"
+	}
+	return fmt.Sprintf("type %q", byte(t))
+}
+
+// tarBlock is the size of a tar header and the data blocks.
+const tarBlock = 512
+
+// TarHeaders walks the headers of a tar archive to classify the format and return the entries.
+// The GNU long name and long link entries and the pax extended headers are applied to the entry
+// that follows them and are not listed. The walk stops at the first empty block.
+func TarHeaders(r io.ReaderAt) (TarArchive, error) {
+	if r == nil {
+		return TarArchive{}, ErrNilReader
+	}
+	const (
+		longName   = 'L'
+		longLink   = 'K'
+		paxHeader  = 'x'
+		paxGlobal  = 'g'
+		maxSpecial = 1 << 20
+	)
+	tar := TarArchive{Entries: []TarEntry{}}
+	var long, link []byte
+	var records map[string]string
+	global := map[string]string{}
+	offset := int64(0)
+	for range maxBlocks {
+		p := make([]byte, tarBlock)
+		if n, _ := r.ReadAt(p, offset); n < tarBlock {
+			if len(tar.Entries) == 0 {
+				return tar, fmt.Errorf("%w: short header at %d", ErrTarHeader, offset)
+			}
+			// some archivers do not write the end of archive blocks
+			return tar, nil
+		}
+		if bytes.Count(p, []byte{0}) == tarBlock {
+			return tar, nil
+		}
+		if !tarChecksum(p) {
+			return tar, fmt.Errorf("%w: checksum at %d", ErrTarHeader, offset)
+		}
+		tar.Format = max(tar.Format, tarMagic(p))
+		size, ok := tarNumber(p[124:136])
+		if !ok || size < 0 {
+			return tar, fmt.Errorf("%w: size at %d", ErrTarHeader, offset)
+		}
+		data := offset + tarBlock
+		offset = data + (size+tarBlock-1)/tarBlock*tarBlock
+		typ := TarType(p[156])
+		switch typ {
+		case longName, longLink, paxHeader, paxGlobal:
+			if size > maxSpecial {
+				return tar, fmt.Errorf("%w: extended header size at %d", ErrTarHeader, data)
+			}
+			b := make([]byte, size)
+			if _, err := r.ReadAt(b, data); err != nil {
+				return tar, fmt.Errorf("%w: extended header at %d", ErrTarHeader, data)
+			}
+			switch typ {
+			case longName:
+				tar.Format = max(tar.Format, TarGNU)
+				long = cstring(b)
+			case longLink:
+				tar.Format = max(tar.Format, TarGNU)
+				link = cstring(b)
+			case paxHeader:
+				tar.Format = TarPAX
+				records = tarRecords(b)
+			case paxGlobal:
+				tar.Format = TarPAX
+				for k, v := range tarRecords(b) {
+					global[k] = v
+				}
+			}
+			continue
+		}
+		entry := tarEntry(p, tarMagic(p) == TarUstar)
+		entry.Offset, entry.Size = data, size
+		if long != nil {
+			entry.Name = string(long)
+		}
+		if link != nil {
+			entry.Linkname = string(link)
+		}
+		if len(global) > 0 || len(records) > 0 {
+			entry.Records = map[string]string{}
+			for k, v := range global {
+				entry.Records[k] = v
+			}
+			for k, v := range records {
+				entry.Records[k] = v
+			}
+			entry.pax()
+		}
+		tar.Entries = append(tar.Entries, entry)
+		long, link, records = nil, nil, nil
+		if entry.Size != size {
+			// the pax size record replaces the size field for large files
+			offset = data + (entry.Size+tarBlock-1)/tarBlock*tarBlock
+		}
+	}
+	return tar, fmt.Errorf("%w: too many entries", ErrTarHeader)
+}
+
+// tarEntry returns the entry of the header block, the prefix field is only used by the ustar format.
+func tarEntry(p []byte, ustar bool) TarEntry {
+	mode, _ := tarNumber(p[100:108])
+	mtime, _ := tarNumber(p[136:148])
+	entry := TarEntry{
+		Name:     string(cstring(p[0:100])),
+		Linkname: string(cstring(p[157:257])),
+		Mode:     mode,
+		Modified: time.Unix(mtime, 0).UTC(),
+		Type:     TarType(p[156]),
+	}
+	if tarMagic(p) != TarV7 {
+		entry.Uname = string(cstring(p[265:297]))
+		entry.Gname = string(cstring(p[297:329]))
+	}
+	if prefix := cstring(p[345:500]); ustar && len(prefix) > 0 {
+		entry.Name = string(prefix) + "/" + entry.Name
+	}
+	return entry
+}
+
+// pax applies the path, linkpath, size and mtime records to the entry.
+func (e *TarEntry) pax() {
+	if s, ok := e.Records["path"]; ok {
+		e.Name = s
+	}
+	if s, ok := e.Records["linkpath"]; ok {
+		e.Linkname = s
+	}
+	if s, ok := e.Records["uname"]; ok {
+		e.Uname = s
+	}
+	if s, ok := e.Records["gname"]; ok {
+		e.Gname = s
+	}
+	if i, err := strconv.ParseInt(e.Records["size"], 10, 64); err == nil && i >= 0 {
+		e.Size = i
+	}
+	if s, ok := e.Records["mtime"]; ok {
+		sec, frac, _ := strings.Cut(s, ".")
+		if i, err := strconv.ParseInt(sec, 10, 64); err == nil {
+			nsec, _ := strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+			e.Modified = time.Unix(i, nsec).UTC()
+		}
+	}
+}
+
+// tarRecords returns the pax extended header records, which use the "length key=value\n" format.
+func tarRecords(b []byte) map[string]string {
+	records := map[string]string{}
+	for len(b) > 0 {
+		num, _, ok := bytes.Cut(b, []byte{' '})
+		length, err := strconv.Atoi(string(num))
+		if !ok || err != nil || length <= len(num)+1 || length > len(b) {
+			break
+		}
+		record := b[len(num)+1 : length]
+		record = bytes.TrimSuffix(record, []byte{'\n'})
+		if k, v, ok := bytes.Cut(record, []byte{'='}); ok {
+			records[string(k)] = string(v)
+		}
+		b = b[length:]
+	}
+	return records
+}
+
+// tarMagic returns the format of the magic value of the header block.
+func tarMagic(p []byte) TarFormat {
+	switch magic := p[257:265]; {
+	case bytes.Equal(magic, []byte("ustar  \x00")):
+		return TarGNU
+	case bytes.HasPrefix(magic, []byte("ustar\x00")):
+		return TarUstar
+	}
+	return TarV7
+}
+
+// tarChecksum returns true if the checksum field of the header block matches the sum of the bytes
+// of the header, where the checksum field is counted as spaces. Some historic archivers used
+// a signed sum, which is also accepted.
+func tarChecksum(p []byte) bool {
+	if len(p) < tarBlock || p[0] == 0 {
+		return false
+	}
+	const start, end = 148, 156
+	want, ok := tarNumber(p[start:end])
+	if !ok {
+		return false
+	}
+	var unsigned, signed int64
+	for i, b := range p[:tarBlock] {
+		if i >= start && i < end {
+			b = ' '
+		}
+		unsigned += int64(b)
+		signed += int64(int8(b))
+	}
+	return want == unsigned || want == signed
+}
+
+// tarNumber parses the octal number of a header field, or the GNU base-256
+// encoding that sets the high bit of the first byte.
+func tarNumber(field []byte) (int64, bool) {
+	if len(field) > 0 && field[0]&0x80 != 0 {
+		var v int64
+		for i, b := range field {
+			if i == 0 {
+				b &= 0x7f
+			}
+			v = v<<8 | int64(b)
+		}
+		return v, true
+	}
+	s := strings.Trim(string(field), " \x00")
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s, 8, 64)
+	return v, err == nil
+}
+
+// cstring returns the bytes before the first NUL.
+func cstring(b []byte) []byte {
+	s, _, _ := bytes.Cut(b, []byte{0})
+	return s
+}
@@ -0,0 +1,132 @@
+package magicnumber_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+// tarArchive returns a tar archive of the format with a file of the name and content.
+func tarArchive(t *testing.T, format tar.Format, name, content string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(content)),
+		ModTime: time.Date(1993, time.April, 1, 12, 0, 0, 0, time.UTC),
+		Uname:   "sysop",
+		Format:  format,
+	}
+	be.Err(t, tw.WriteHeader(hdr), nil)
+	_, err := tw.Write([]byte(content))
+	be.Err(t, err, nil)
+	be.Err(t, tw.Close(), nil)
+	return buf.Bytes()
+}
+
+func TestTarHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestTarHeaders")
+	_, err := magicnumber.TarHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(tarFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	arc, err := magicnumber.TarHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.TarGNU, arc.Format)
+	be.Equal(t, "GNU", arc.Format.String())
+	be.Equal(t, 15, len(arc.Entries))
+	ans := arc.Entries[0]
+	be.Equal(t, "TEST.ANS", ans.Name)
+	be.Equal(t, "file", ans.Type.String())
+	be.Equal(t, int64(68), ans.Size)
+	be.Equal(t, int64(512), ans.Offset)
+	be.Equal(t, int64(0o664), ans.Mode)
+	be.Equal(t, "ben", ans.Uname)
+	be.Equal(t, time.Date(2012, time.September, 19, 4, 21, 52, 0, time.UTC), ans.Modified)
+	be.Equal(t, "TEST.EXE", arc.Entries[6].Name)
+	be.Equal(t, int64(2426368), arc.Entries[6].Size)
+
+	_, err = magicnumber.TarHeaders(bytes.NewReader(bytes.Repeat([]byte("not a tar "), 100)))
+	be.Err(t, err, magicnumber.ErrTarHeader)
+}
+
+func TestTarFormats(t *testing.T) {
+	t.Parallel()
+	t.Log("TestTarFormats")
+	long := strings.Repeat("directory/", 12) + "FILE_ID.DIZ"
+	// a filename that cannot be split into the prefix and name fields of ustar
+	unsplit := strings.Repeat("x", 120) + ".txt"
+	tests := []struct {
+		format tar.Format
+		name   string
+		want   magicnumber.TarFormat
+	}{
+		{tar.FormatUSTAR, "FILE_ID.DIZ", magicnumber.TarUstar},
+		{tar.FormatUSTAR, long, magicnumber.TarUstar},
+		{tar.FormatGNU, long, magicnumber.TarGNU},
+		{tar.FormatPAX, unsplit, magicnumber.TarPAX},
+		{tar.FormatPAX, "ƒιℓε.txt", magicnumber.TarPAX},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.want, " ", len(tt.name)), func(t *testing.T) {
+			t.Parallel()
+			b := tarArchive(t, tt.format, tt.name, "hello")
+			be.True(t, magicnumber.Tar(bytes.NewReader(b)))
+			arc, err := magicnumber.TarHeaders(bytes.NewReader(b))
+			be.Err(t, err, nil)
+			be.Equal(t, tt.want, arc.Format)
+			be.Equal(t, 1, len(arc.Entries))
+			e := arc.Entries[0]
+			be.Equal(t, tt.name, e.Name)
+			be.Equal(t, int64(5), e.Size)
+			be.Equal(t, "sysop", e.Uname)
+			p := make([]byte, e.Size)
+			_, err = bytes.NewReader(b).ReadAt(p, e.Offset)
+			be.Err(t, err, nil)
+			be.Equal(t, "hello", string(p))
+			if tt.want == magicnumber.TarPAX {
+				be.Equal(t, tt.name, e.Records["path"])
+			}
+		})
+	}
+}
+
+func TestTarV7(t *testing.T) {
+	t.Parallel()
+	t.Log("TestTarV7")
+	b := tarArchive(t, tar.FormatUSTAR, "README.TXT", "hello")
+	// remove the ustar magic, version and owner names, then update the checksum
+	clear(b[257:345])
+	copy(b[148:156], "        ")
+	sum := 0
+	for _, c := range b[:512] {
+		sum += int(c)
+	}
+	copy(b[148:156], fmt.Sprintf("%06o\x00 ", sum))
+	be.True(t, magicnumber.Tar(bytes.NewReader(b)))
+	sign, err := magicnumber.Archive(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.TapeARchive, sign)
+	arc, err := magicnumber.TarHeaders(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.TarV7, arc.Format)
+	be.Equal(t, 1, len(arc.Entries))
+	be.Equal(t, "README.TXT", arc.Entries[0].Name)
+	be.Equal(t, "", arc.Entries[0].Uname)
+
+	// a corrupt checksum is not a tar archive
+	b[0] = 'r'
+	be.True(t, !magicnumber.Tar(bytes.NewReader(b)))
+}
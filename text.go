@@ -265,13 +265,6 @@ func Ansi(r io.ReaderAt) bool {
 //
 // The writer is optional for debug output but can usually be [io.Discard].
 func AnsiW(w io.Writer, r io.ReaderAt) bool {
-	const esc = 0x1b
-	var (
-		reset   = []byte{esc, '[', '0', 'm'}
-		restart = []byte{esc, '[', '2', 'J'}
-		bold    = []byte{esc, '[', '1', ';'}
-		normal  = []byte{esc, '[', '0', ';'}
-	)
 	// check for the common ANSI escape codes
 	size := Length(r)
 	const chunkSize = 1024
@@ -287,20 +280,8 @@ func AnsiW(w io.Writer, r io.ReaderAt) bool {
 			ansiln(w, fmt.Sprintf("error, bytes to read %d, offset %d, %s", bytesToRead, offset, err))
 			return false
 		}
-		if pos := bytes.Index(buf[:n], reset); pos != -1 {
-			ansiln(w, fmt.Sprintf("reset, position %d", pos))
-			return true
-		}
-		if pos := bytes.Index(buf[:n], restart); pos != -1 {
-			ansiln(w, fmt.Sprintf("restart, position %d", pos))
-			return true
-		}
-		if pos := bytes.Index(buf[:n], bold); pos != -1 {
-			ansiln(w, fmt.Sprintf("bold, position %d", pos))
-			return true
-		}
-		if pos := bytes.Index(buf[:n], normal); pos != -1 {
-			ansiln(w, fmt.Sprintf("normal, position %d", pos))
+		if code, pos := ansiCode(buf[:n]); pos != -1 {
+			ansiln(w, fmt.Sprintf("%s, position %d", code, pos))
 			return true
 		}
 		if err == io.EOF {
@@ -312,6 +293,27 @@ func AnsiW(w io.Writer, r io.ReaderAt) bool {
 	return false
 }
 
+// ansiCode returns the name and the position of the first common ANSI escape code
+// found in the byte slice, or an empty name and -1 if none are found.
+func ansiCode(p []byte) (string, int) {
+	const esc = 0x1b
+	codes := []struct {
+		name string
+		seq  []byte
+	}{
+		{"reset", []byte{esc, '[', '0', 'm'}},
+		{"restart", []byte{esc, '[', '2', 'J'}},
+		{"bold", []byte{esc, '[', '1', ';'}},
+		{"normal", []byte{esc, '[', '0', ';'}},
+	}
+	for _, code := range codes {
+		if pos := bytes.Index(p, code.seq); pos != -1 {
+			return code.name, pos
+		}
+	}
+	return "", -1
+}
+
 func ansiln(w io.Writer, s string) {
 	if w == nil {
 		return
@@ -454,6 +456,68 @@ func threshold(count int, size int64) bool {
 	return float64(count)/float64(size) < percentage
 }
 
+// TextStats are the statistics collected by the text heuristics [TxtW], [CodePageW] and [AnsiW].
+// Unlike the heuristics, which return as soon as they can decide, the statistics are
+// gathered from the whole of the reader.
+type TextStats struct {
+	Size         int64   // Size is the length of the reader in bytes
+	NonPlainText int     // NonPlainText is the count of bytes that are not plain text characters
+	Ratio        float64 // Ratio of non-plain text bytes to the size, TxtW rejects a ratio of 0.02 or more
+	Newlines     int     // Newlines is the count of IBM PC/Microsoft newlines, a carriage return and line feed
+	Escape       string  // Escape is the name of the first common ANSI escape code found or empty
+	EscapeOffset int64   // EscapeOffset is the offset of the ANSI escape code or -1 if none was found
+}
+
+// TextStatistics reads the whole of the reader and returns the statistics used by
+// the plain text and ANSI text heuristics. It is intended for debugging misidentified files.
+func TextStatistics(r io.ReaderAt) TextStats {
+	stats := TextStats{
+		Size:         Length(r),
+		EscapeOffset: -1,
+	}
+	msdosNL := []byte{0x0d, 0x0a}
+	const chunkSize = 1024
+	buf := make([]byte, chunkSize)
+	// the end of the previous chunk is carried to find the newlines and
+	// the escape codes that are split across the chunks
+	const maxCode = 4
+	tail := make([]byte, 0, maxCode-1)
+	for offset := int64(0); offset < stats.Size; offset += chunkSize {
+		bytesToRead := chunkSize
+		if offset+int64(chunkSize) > stats.Size {
+			bytesToRead = int(stats.Size - offset)
+		}
+		n, err := r.ReadAt(buf[:bytesToRead], offset)
+		if err != nil && err != io.EOF {
+			break
+		}
+		for i := range n {
+			if NotPlainText(buf[i]) {
+				stats.NonPlainText++
+			}
+		}
+		stats.Newlines += bytes.Count(buf[:n], msdosNL)
+		if n > 0 && len(tail) > 0 && tail[len(tail)-1] == msdosNL[0] && buf[0] == msdosNL[1] {
+			stats.Newlines++
+		}
+		if stats.EscapeOffset == -1 {
+			window := append(tail, buf[:n]...)
+			if code, pos := ansiCode(window); pos != -1 {
+				stats.Escape = code
+				stats.EscapeOffset = offset - int64(len(tail)) + int64(pos)
+			}
+		}
+		tail = append(tail[:0], buf[max(0, n-(maxCode-1)):n]...)
+		if err == io.EOF {
+			break
+		}
+	}
+	if stats.Size > 0 {
+		stats.Ratio = float64(stats.NonPlainText) / float64(stats.Size)
+	}
+	return stats
+}
+
 // TxtLatin1 returns true if the reader exclusively contains plain text ISO/IEC-8895-1 characters,
 // commonly known as the Latin-1 character set.
 func TxtLatin1(r io.ReaderAt) bool {
@@ -227,3 +227,34 @@ func TestBinaryTexts(t *testing.T) {
 	defer r.Close()
 	be.True(t, magicnumber.XBin(r))
 }
+
+func TestTextStatistics(t *testing.T) {
+	t.Parallel()
+	r, err := os.Open(uncompress(ansiFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	stats := magicnumber.TextStatistics(r)
+	be.True(t, stats.Size > 0)
+	be.True(t, stats.EscapeOffset > -1)
+	be.True(t, stats.Escape != "")
+
+	s := "line one\r\nline two\r\n\x01\x02"
+	stats = magicnumber.TextStatistics(strings.NewReader(s))
+	be.Equal(t, int64(len(s)), stats.Size)
+	be.Equal(t, 2, stats.Newlines)
+	be.Equal(t, 2, stats.NonPlainText)
+	be.Equal(t, float64(2)/float64(len(s)), stats.Ratio)
+	be.Equal(t, "", stats.Escape)
+	be.Equal(t, int64(-1), stats.EscapeOffset)
+
+	stats = magicnumber.TextStatistics(strings.NewReader("ANSI \x1b[2Jtext"))
+	be.Equal(t, "restart", stats.Escape)
+	be.Equal(t, int64(5), stats.EscapeOffset)
+
+	// a newline and an escape code that are split across the 1024 byte chunks
+	s = strings.Repeat("a", 1023) + "\r\n" + strings.Repeat("b", 1020) + "\x1b[0m"
+	stats = magicnumber.TextStatistics(strings.NewReader(s))
+	be.Equal(t, 1, stats.Newlines)
+	be.Equal(t, "reset", stats.Escape)
+	be.Equal(t, int64(2045), stats.EscapeOffset)
+}
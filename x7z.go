@@ -0,0 +1,706 @@
+package magicnumber
+
+// Package file x7z.go contains the functions that parse the headers of the 7z archive format by Igor Pavlov.
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+	"time"
+	"unicode/utf16"
+)
+
+var ErrX7zHeader = errors.New("7z header is invalid")
+
+// X7zArchive is the header of a 7z archive.
+type X7zArchive struct {
+	Version         string    // Version is the version of the 7z format, such as 0.4
+	Coders          []string  // Coders are the names of the methods used by the packed streams, such as LZMA2 or BCJ
+	Files           []X7zFile // Files are the file and directory entries of the archive, in the stored order
+	Blocks          int       // Blocks is the number of folders, which are the compressed blocks of the archive
+	Solid           bool      // Solid is true if a block contains more than one file
+	EncodedHeader   bool      // EncodedHeader is true if the header is compressed
+	EncryptedHeader bool      // EncryptedHeader is true if the header is encrypted and the files cannot be listed
+}
+
+// X7zFile is a file or directory entry of a 7z archive.
+type X7zFile struct {
+	Name     string    // Name is the path of the file
+	Modified time.Time // Modified is the date and time the file was last modified
+	Size     int64     // Size is the size of the file when uncompressed
+	Dir      bool      // Dir is true if the entry is a directory
+}
+
+// x7zIDs are the property ids of the 7z header.
+const (
+	x7zEnd = iota
+	x7zHeader
+	x7zArchiveProperties
+	x7zAdditionalStreams
+	x7zMainStreams
+	x7zFilesInfo
+	x7zPackInfo
+	x7zUnpackInfo
+	x7zSubStreams
+	x7zSize
+	x7zCRC
+	x7zFolderID
+	x7zCodersUnpackSize
+	x7zNumUnpackStream
+	x7zEmptyStream
+	x7zEmptyFile
+	x7zAnti
+	x7zName
+	x7zCTime
+	x7zATime
+	x7zMTime
+	x7zWinAttributes
+	x7zComment
+	x7zEncodedHeader
+)
+
+// X7zHeaders reads the start header of a 7z archive, follows the offset to the next header
+// and decodes the header to return the coders, the number of blocks and the files of the archive.
+// Headers that are compressed with LZMA or LZMA2 are decompressed, while encrypted headers
+// only report the coders of the header.
+func X7zHeaders(r io.ReaderAt) (X7zArchive, error) {
+	if r == nil {
+		return X7zArchive{}, ErrNilReader
+	}
+	const startHeaderLen, maxHeader = 32, 64 << 20
+	p := make([]byte, startHeaderLen)
+	// the matcher also checks the start header checksum
+	if n, _ := r.ReadAt(p, 0); n < startHeaderLen || !X7z(r) {
+		return X7zArchive{}, fmt.Errorf("%w: no signature", ErrX7zHeader)
+	}
+	le := binary.LittleEndian
+	arc := X7zArchive{
+		Version: fmt.Sprintf("%d.%d", p[6], p[7]),
+		Coders:  []string{},
+		Files:   []X7zFile{},
+	}
+	offset, size := le.Uint64(p[12:]), le.Uint64(p[20:])
+	if size == 0 {
+		return arc, nil
+	}
+	if size > maxHeader || offset > 1<<62 {
+		return arc, fmt.Errorf("%w: next header size %d", ErrX7zHeader, size)
+	}
+	head := make([]byte, size)
+	if _, err := r.ReadAt(head, int64(startHeaderLen+offset)); err != nil {
+		return arc, fmt.Errorf("%w: next header at %d", ErrX7zHeader, startHeaderLen+offset)
+	}
+	if crc32.ChecksumIEEE(head) != le.Uint32(p[28:]) {
+		return arc, fmt.Errorf("%w: next header checksum", ErrX7zHeader)
+	}
+	const maxEncodings = 4
+	for range maxEncodings {
+		b := &x7zBuffer{p: head}
+		switch b.byte() {
+		case x7zHeader:
+			if err := arc.header(b); err != nil {
+				return arc, err
+			}
+			return arc, nil
+		case x7zEncodedHeader:
+			arc.EncodedHeader = true
+			var err error
+			head, err = arc.decode(r, b, maxHeader)
+			if err != nil || head == nil {
+				return arc, err
+			}
+		default:
+			return arc, fmt.Errorf("%w: unknown next header", ErrX7zHeader)
+		}
+	}
+	return arc, fmt.Errorf("%w: too many header encodings", ErrX7zHeader)
+}
+
+// decode reads the streams info of an encoded header and returns the decompressed header.
+// A nil header and error is returned for an encrypted header.
+func (arc *X7zArchive) decode(r io.ReaderAt, b *x7zBuffer, maxHeader uint64) ([]byte, error) {
+	info, err := x7zStreams(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.folders) == 0 || len(info.packSizes) == 0 {
+		return nil, fmt.Errorf("%w: encoded header has no streams", ErrX7zHeader)
+	}
+	folder := info.folders[0]
+	for _, c := range folder.coders {
+		if x7zCoder(c.id) == "AES" {
+			arc.EncryptedHeader = true
+			arc.addCoders(info)
+			return nil, nil
+		}
+	}
+	const startHeaderLen = 32
+	packSize, size := info.packSizes[0], folder.unpackSize()
+	if packSize > maxHeader || size > maxHeader {
+		return nil, fmt.Errorf("%w: encoded header size", ErrX7zHeader)
+	}
+	packed := make([]byte, packSize)
+	if _, err := r.ReadAt(packed, int64(startHeaderLen+info.packPos)); err != nil {
+		return nil, fmt.Errorf("%w: encoded header at %d", ErrX7zHeader, startHeaderLen+info.packPos)
+	}
+	if len(folder.coders) != 1 {
+		return nil, fmt.Errorf("%w: unsupported encoded header coders", ErrX7zHeader)
+	}
+	var head []byte
+	switch coder := folder.coders[0]; x7zCoder(coder.id) {
+	case "Copy":
+		head = packed
+	case "LZMA":
+		head, err = lzmaDecode(coder.props, packed, int(size))
+	case "LZMA2":
+		head, err = lzma2Decode(packed, int(size))
+	default:
+		return nil, fmt.Errorf("%w: unsupported encoded header coder %s", ErrX7zHeader, x7zCoder(coder.id))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: encoded header: %w", ErrX7zHeader, err)
+	}
+	if folder.crcDefined && crc32.ChecksumIEEE(head) != folder.crc {
+		return nil, fmt.Errorf("%w: encoded header checksum", ErrX7zHeader)
+	}
+	return head, nil
+}
+
+// header reads the properties of the header that follow the header id.
+func (arc *X7zArchive) header(b *x7zBuffer) error {
+	id := b.byte()
+	if id == x7zArchiveProperties {
+		for b.err == nil {
+			if b.byte() == x7zEnd {
+				break
+			}
+			b.skip(b.number())
+		}
+		id = b.byte()
+	}
+	if id == x7zAdditionalStreams {
+		if _, err := x7zStreams(b); err != nil {
+			return err
+		}
+		id = b.byte()
+	}
+	var info x7zStreamsInfo
+	if id == x7zMainStreams {
+		var err error
+		if info, err = x7zStreams(b); err != nil {
+			return err
+		}
+		arc.addCoders(info)
+		arc.Blocks = len(info.folders)
+		for _, n := range info.streams {
+			if n > 1 {
+				arc.Solid = true
+			}
+		}
+		id = b.byte()
+	}
+	if id == x7zFilesInfo {
+		if err := arc.files(b, info.sizes); err != nil {
+			return err
+		}
+		id = b.byte()
+	}
+	if b.err != nil || id != x7zEnd {
+		return fmt.Errorf("%w: header property %d", ErrX7zHeader, id)
+	}
+	return nil
+}
+
+// addCoders appends the names of the coders that are not yet listed.
+func (arc *X7zArchive) addCoders(info x7zStreamsInfo) {
+	for _, folder := range info.folders {
+		for _, c := range folder.coders {
+			if name := x7zCoder(c.id); !slices.Contains(arc.Coders, name) {
+				arc.Coders = append(arc.Coders, name)
+			}
+		}
+	}
+}
+
+// files reads the files info, where sizes are the unpacked sizes of the streams.
+func (arc *X7zArchive) files(b *x7zBuffer, sizes []uint64) error {
+	const maxFiles = 1 << 24
+	count := b.number()
+	// every file has a name that uses at least the two bytes of the NUL terminator,
+	// so the count cannot be more than the remaining bytes of the header
+	if count > maxFiles || count > uint64(len(b.p)) {
+		return fmt.Errorf("%w: %d files", ErrX7zHeader, count)
+	}
+	files := make([]X7zFile, count)
+	var emptyStream, emptyFile []bool
+	for b.err == nil {
+		prop := b.byte()
+		if prop == x7zEnd {
+			break
+		}
+		size := b.number()
+		data := &x7zBuffer{p: b.bytes(size)}
+		switch prop {
+		case x7zEmptyStream:
+			emptyStream = data.bits(int(count))
+		case x7zEmptyFile:
+			emptyFile = data.bits(countTrue(emptyStream))
+		case x7zName:
+			if data.byte() != 0 {
+				continue
+			}
+			names := utf16.Decode(x7zUTF16(data.p))
+			for i, name := range splitNul(names) {
+				if i < len(files) {
+					files[i].Name = name
+				}
+			}
+		case x7zMTime:
+			defined := data.defined(int(count))
+			if data.byte() != 0 {
+				continue
+			}
+			for i := range files {
+				if defined[i] {
+					files[i].Modified = filetime(data.uint64())
+				}
+			}
+		case x7zWinAttributes:
+			defined := data.defined(int(count))
+			if data.byte() != 0 {
+				continue
+			}
+			const directory = 0x10
+			for i := range files {
+				if defined[i] && data.uint32()&directory != 0 {
+					files[i].Dir = true
+				}
+			}
+		}
+	}
+	empty, stream := 0, 0
+	for i := range files {
+		if i < len(emptyStream) && emptyStream[i] {
+			if empty >= len(emptyFile) || !emptyFile[empty] {
+				files[i].Dir = true
+			}
+			empty++
+			continue
+		}
+		if stream < len(sizes) {
+			files[i].Size = int64(sizes[stream])
+		}
+		stream++
+	}
+	arc.Files = files
+	if b.err != nil {
+		return fmt.Errorf("%w: files info", ErrX7zHeader)
+	}
+	return nil
+}
+
+// x7zStreamsInfo are the pack info, the folders and the sub-streams of a 7z header.
+type x7zStreamsInfo struct {
+	packPos   uint64
+	packSizes []uint64
+	folders   []x7zFolder
+	streams   []uint64 // streams are the number of unpacked streams of each folder
+	sizes     []uint64 // sizes are the unpacked sizes of the streams
+}
+
+// x7zFolder is a folder of coders, which is a compressed block.
+type x7zFolder struct {
+	coders      []x7zCoderInfo
+	bindOut     []uint64
+	unpackSizes []uint64
+	crc         uint32
+	crcDefined  bool
+}
+
+// unpackSize returns the size of the output stream that is not bound to another coder.
+func (f x7zFolder) unpackSize() uint64 {
+	for i, size := range f.unpackSizes {
+		if !slices.Contains(f.bindOut, uint64(i)) {
+			return size
+		}
+	}
+	return 0
+}
+
+// x7zCoderInfo is a coder of a folder.
+type x7zCoderInfo struct {
+	id       []byte
+	props    []byte
+	inCount  uint64
+	outCount uint64
+}
+
+// x7zStreams reads the streams info.
+func x7zStreams(b *x7zBuffer) (x7zStreamsInfo, error) {
+	var info x7zStreamsInfo
+	const maxItems = 1 << 24
+	id := b.byte()
+	if id == x7zPackInfo {
+		info.packPos = b.number()
+		count := b.number()
+		// every pack size uses at least one byte of the header
+		if count > maxItems || count > uint64(len(b.p)) {
+			return info, fmt.Errorf("%w: %d pack streams", ErrX7zHeader, count)
+		}
+		for b.err == nil {
+			prop := b.byte()
+			if prop == x7zEnd {
+				break
+			}
+			switch prop {
+			case x7zSize:
+				for range count {
+					if b.err != nil {
+						break
+					}
+					info.packSizes = append(info.packSizes, b.number())
+				}
+			case x7zCRC:
+				b.digests(int(count))
+			default:
+				b.skip(b.number())
+			}
+		}
+		id = b.byte()
+	}
+	if id == x7zUnpackInfo {
+		if err := info.unpackInfo(b); err != nil {
+			return info, err
+		}
+		id = b.byte()
+	}
+	info.streams = make([]uint64, len(info.folders))
+	for i, folder := range info.folders {
+		info.streams[i] = 1
+		info.sizes = append(info.sizes, folder.unpackSize())
+	}
+	if id == x7zSubStreams {
+		if err := info.subStreams(b); err != nil {
+			return info, err
+		}
+		id = b.byte()
+	}
+	if b.err != nil || id != x7zEnd {
+		return info, fmt.Errorf("%w: streams info property %d", ErrX7zHeader, id)
+	}
+	return info, nil
+}
+
+// unpackInfo reads the folders and their unpacked sizes.
+func (info *x7zStreamsInfo) unpackInfo(b *x7zBuffer) error {
+	const maxItems = 1 << 16
+	if b.byte() != x7zFolderID {
+		return fmt.Errorf("%w: no folders", ErrX7zHeader)
+	}
+	count := b.number()
+	if count > maxItems || b.byte() != 0 {
+		return fmt.Errorf("%w: folders", ErrX7zHeader)
+	}
+	for range count {
+		folder, err := x7zReadFolder(b)
+		if err != nil {
+			return err
+		}
+		info.folders = append(info.folders, folder)
+	}
+	if b.byte() != x7zCodersUnpackSize {
+		return fmt.Errorf("%w: no unpack sizes", ErrX7zHeader)
+	}
+	for i := range info.folders {
+		outs := uint64(0)
+		for _, c := range info.folders[i].coders {
+			outs += c.outCount
+		}
+		for range outs {
+			info.folders[i].unpackSizes = append(info.folders[i].unpackSizes, b.number())
+		}
+	}
+	for b.err == nil {
+		prop := b.byte()
+		if prop == x7zEnd {
+			break
+		}
+		if prop != x7zCRC {
+			b.skip(b.number())
+			continue
+		}
+		defined := b.defined(len(info.folders))
+		for i := range info.folders {
+			if defined[i] {
+				info.folders[i].crcDefined = true
+				info.folders[i].crc = b.uint32()
+			}
+		}
+	}
+	if b.err != nil {
+		return fmt.Errorf("%w: unpack info", ErrX7zHeader)
+	}
+	return nil
+}
+
+// subStreams reads the number of unpacked streams of each folder and their sizes.
+func (info *x7zStreamsInfo) subStreams(b *x7zBuffer) error {
+	const maxItems = 1 << 24
+	id := b.byte()
+	if id == x7zNumUnpackStream {
+		// every stream is a file that is listed by the files info after the streams info,
+		// so the total of the streams cannot be more than the remaining bytes of the header
+		var total uint64
+		for i := range info.streams {
+			info.streams[i] = b.number()
+			if total += info.streams[i]; info.streams[i] > maxItems || total > uint64(len(b.p)) {
+				return fmt.Errorf("%w: %d streams", ErrX7zHeader, info.streams[i])
+			}
+		}
+		id = b.byte()
+	}
+	info.sizes = info.sizes[:0]
+	for i, folder := range info.folders {
+		count := info.streams[i]
+		if count == 0 {
+			continue
+		}
+		total, sum := folder.unpackSize(), uint64(0)
+		for range count - 1 {
+			if id != x7zSize || b.err != nil {
+				break
+			}
+			size := b.number()
+			info.sizes = append(info.sizes, size)
+			sum += size
+		}
+		if id == x7zSize || count == 1 {
+			info.sizes = append(info.sizes, total-min(sum, total))
+		}
+	}
+	if id == x7zSize {
+		id = b.byte()
+	}
+	for b.err == nil && id != x7zEnd {
+		if id == x7zCRC {
+			digests := 0
+			for i, folder := range info.folders {
+				if info.streams[i] != 1 || !folder.crcDefined {
+					digests += int(info.streams[i])
+				}
+			}
+			b.digests(digests)
+		} else {
+			b.skip(b.number())
+		}
+		id = b.byte()
+	}
+	if b.err != nil {
+		return fmt.Errorf("%w: sub-streams info", ErrX7zHeader)
+	}
+	return nil
+}
+
+// x7zReadFolder reads the coders and the bind pairs of a folder.
+func x7zReadFolder(b *x7zBuffer) (x7zFolder, error) {
+	const (
+		maxCoders  = 64
+		idSize     = 0x0f
+		complexID  = 0x10
+		attributes = 0x20
+	)
+	var folder x7zFolder
+	count := b.number()
+	if count == 0 || count > maxCoders {
+		return folder, fmt.Errorf("%w: %d coders", ErrX7zHeader, count)
+	}
+	var ins, outs uint64
+	for range count {
+		flags := b.byte()
+		c := x7zCoderInfo{id: b.bytes(uint64(flags & idSize)), inCount: 1, outCount: 1}
+		if flags&complexID != 0 {
+			c.inCount, c.outCount = b.number(), b.number()
+		}
+		if flags&attributes != 0 {
+			c.props = b.bytes(b.number())
+		}
+		if c.inCount > maxCoders || c.outCount > maxCoders {
+			return folder, fmt.Errorf("%w: coder streams", ErrX7zHeader)
+		}
+		ins += c.inCount
+		outs += c.outCount
+		folder.coders = append(folder.coders, c)
+	}
+	if outs == 0 {
+		return folder, fmt.Errorf("%w: no coder outputs", ErrX7zHeader)
+	}
+	for range outs - 1 {
+		_ = b.number()
+		folder.bindOut = append(folder.bindOut, b.number())
+	}
+	if packed := ins - min(ins, outs-1); packed > 1 {
+		for range packed {
+			_ = b.number()
+		}
+	}
+	if b.err != nil {
+		return folder, fmt.Errorf("%w: folder", ErrX7zHeader)
+	}
+	return folder, nil
+}
+
+// x7zCoder returns the name of the coder id.
+func x7zCoder(id []byte) string {
+	names := map[string]string{
+		"00": "Copy", "03": "Delta", "04": "BCJ", "05": "PPC", "06": "IA64", "07": "ARM",
+		"08": "ARMT", "09": "SPARC", "0a": "ARM64", "21": "LZMA2",
+		"030101": "LZMA", "030401": "PPMd", "03030103": "BCJ", "0303011b": "BCJ2",
+		"03030205": "PPC", "03030401": "IA64", "03030501": "ARM", "03030701": "ARMT",
+		"03030805": "SPARC", "040108": "Deflate", "040109": "Deflate64", "040202": "BZip2",
+		"06f10701": "AES",
+	}
+	s := hex.EncodeToString(id)
+	if name, ok := names[s]; ok {
+		return name
+	}
+	return "unknown coder " + s
+}
+
+// x7zBuffer reads the values of a 7z header, a read past the end sets the error.
+type x7zBuffer struct {
+	p   []byte
+	err error
+}
+
+func (b *x7zBuffer) bytes(n uint64) []byte {
+	if n > uint64(len(b.p)) {
+		b.err = io.ErrUnexpectedEOF
+		b.p = nil
+		return nil
+	}
+	v := b.p[:n]
+	b.p = b.p[n:]
+	return v
+}
+
+func (b *x7zBuffer) skip(n uint64) {
+	b.bytes(n)
+}
+
+func (b *x7zBuffer) byte() byte {
+	if v := b.bytes(1); v != nil {
+		return v[0]
+	}
+	return 0
+}
+
+func (b *x7zBuffer) uint32() uint32 {
+	if v := b.bytes(4); v != nil {
+		return binary.LittleEndian.Uint32(v)
+	}
+	return 0
+}
+
+func (b *x7zBuffer) uint64() uint64 {
+	if v := b.bytes(8); v != nil {
+		return binary.LittleEndian.Uint64(v)
+	}
+	return 0
+}
+
+// number reads a 7z variable length number, where the count of the high set bits of the first byte
+// is the number of the bytes that follow.
+func (b *x7zBuffer) number() uint64 {
+	first := b.byte()
+	mask := byte(0x80)
+	var v uint64
+	for i := range 8 {
+		if first&mask == 0 {
+			return v | uint64(first&(mask-1))<<(8*i)
+		}
+		v |= uint64(b.byte()) << (8 * i)
+		mask >>= 1
+	}
+	return v
+}
+
+// bits reads a vector of count bits, with the highest bit first.
+func (b *x7zBuffer) bits(count int) []bool {
+	p := b.bytes(uint64((count + 7) / 8))
+	v := make([]bool, count)
+	for i := range v {
+		if p != nil {
+			v[i] = p[i/8]&(0x80>>(i%8)) != 0
+		}
+	}
+	return v
+}
+
+// defined reads the all defined byte, which is followed by a bit vector when not all items are defined.
+func (b *x7zBuffer) defined(count int) []bool {
+	if b.byte() == 0 {
+		return b.bits(count)
+	}
+	v := make([]bool, count)
+	for i := range v {
+		v[i] = true
+	}
+	return v
+}
+
+// digests skips the CRC32 digests of count items.
+func (b *x7zBuffer) digests(count int) {
+	for _, ok := range b.defined(count) {
+		if ok {
+			b.uint32()
+		}
+	}
+}
+
+// x7zUTF16 returns the little-endian UTF-16 code units of p.
+func x7zUTF16(p []byte) []uint16 {
+	u := make([]uint16, len(p)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(p[i*2:])
+	}
+	return u
+}
+
+// splitNul returns the strings of the runes that are terminated by NUL.
+func splitNul(runes []rune) []string {
+	var names []string
+	start := 0
+	for i, r := range runes {
+		if r == 0 {
+			names = append(names, string(runes[start:i]))
+			start = i + 1
+		}
+	}
+	return names
+}
+
+// countTrue returns the number of true values.
+func countTrue(v []bool) int {
+	n := 0
+	for _, b := range v {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// filetime returns the time of a Windows FILETIME, which is the number of
+// 100-nanosecond intervals since January 1, 1601 UTC.
+func filetime(ft uint64) time.Time {
+	const epoch, interval = 116444736000000000, 10000000
+	if ft < epoch {
+		return time.Time{}
+	}
+	ft -= epoch
+	return time.Unix(int64(ft/interval), int64(ft%interval)*100).UTC()
+}
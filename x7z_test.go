@@ -0,0 +1,123 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+// x7zArchive returns a 7z archive of the packed streams and the next header with valid checksums.
+func x7zArchive(packed, next []byte) []byte {
+	p := append([]byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0, 4}, make([]byte, 24)...)
+	binary.LittleEndian.PutUint64(p[12:], uint64(len(packed)))
+	binary.LittleEndian.PutUint64(p[20:], uint64(len(next)))
+	binary.LittleEndian.PutUint32(p[28:], crc32.ChecksumIEEE(next))
+	binary.LittleEndian.PutUint32(p[8:], crc32.ChecksumIEEE(p[12:32]))
+	p = append(p, packed...)
+	return append(p, next...)
+}
+
+func TestX7zHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestX7zHeaders")
+	_, err := magicnumber.X7zHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(x7zFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	arc, err := magicnumber.X7zHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, "0.3", arc.Version)
+	be.True(t, arc.EncodedHeader)
+	be.True(t, !arc.EncryptedHeader)
+	be.Equal(t, []string{"LZMA"}, arc.Coders)
+	be.Equal(t, 1, arc.Blocks)
+	be.True(t, arc.Solid)
+	be.Equal(t, 15, len(arc.Files))
+	be.Equal(t, "TEST.ANS", arc.Files[0].Name)
+	be.Equal(t, int64(68), arc.Files[0].Size)
+	be.Equal(t, "TEST.BMP", arc.Files[2].Name)
+	be.Equal(t, int64(750054), arc.Files[2].Size)
+	be.Equal(t, 2024, arc.Files[2].Modified.Year())
+	be.Equal(t, "TEST.TXT", arc.Files[14].Name)
+
+	x7z, err := os.ReadFile(tdfile(x7zFile))
+	be.Err(t, err, nil)
+	x7z[len(x7z)-1] ^= 0xff
+	_, err = magicnumber.X7zHeaders(bytes.NewReader(x7z))
+	be.Err(t, err, magicnumber.ErrX7zHeader)
+	x7z[9] ^= 0xff
+	be.True(t, !magicnumber.X7z(bytes.NewReader(x7z)))
+}
+
+func TestX7zFiles(t *testing.T) {
+	t.Parallel()
+	t.Log("TestX7zFiles")
+	// a header with a copy coder block of two files and an empty directory
+	next := []byte{0x01, 0x04,
+		0x06, 0x00, 0x01, 0x09, 0x05, 0x00,
+		0x07, 0x0b, 0x01, 0x00, 0x01, 0x01, 0x00, 0x0c, 0x05, 0x00,
+		0x08, 0x0d, 0x02, 0x09, 0x02, 0x00,
+		0x00,
+		0x05, 0x03,
+		0x0e, 0x01, 0x40,
+		0x0f, 0x01, 0x00,
+	}
+	var names []byte
+	for _, u := range utf16.Encode([]rune("a.txt\x00docs\x00b.txt\x00")) {
+		names = binary.LittleEndian.AppendUint16(names, u)
+	}
+	next = append(next, 0x11, byte(len(names)+1), 0x00)
+	next = append(next, names...)
+	next = append(next, 0x00, 0x00)
+	arc, err := magicnumber.X7zHeaders(bytes.NewReader(x7zArchive([]byte("hello"), next)))
+	be.Err(t, err, nil)
+	be.True(t, !arc.EncodedHeader)
+	be.Equal(t, []string{"Copy"}, arc.Coders)
+	be.Equal(t, 1, arc.Blocks)
+	be.True(t, arc.Solid)
+	be.Equal(t, []magicnumber.X7zFile{
+		{Name: "a.txt", Size: 2},
+		{Name: "docs", Dir: true},
+		{Name: "b.txt", Size: 3},
+	}, arc.Files)
+
+	// an encoded header that is encrypted with AES-256
+	next = []byte{0x17,
+		0x06, 0x00, 0x01, 0x09, 0x10, 0x00,
+		0x07, 0x0b, 0x01, 0x00, 0x01, 0x24, 0x06, 0xf1, 0x07, 0x01, 0x00, 0x0c, 0x10, 0x00,
+		0x00,
+	}
+	arc, err = magicnumber.X7zHeaders(bytes.NewReader(x7zArchive(make([]byte, 16), next)))
+	be.Err(t, err, nil)
+	be.True(t, arc.EncodedHeader)
+	be.True(t, arc.EncryptedHeader)
+	be.Equal(t, []string{"AES"}, arc.Coders)
+	be.Equal(t, 0, len(arc.Files))
+
+	// a sub-streams count of 16 million streams in a header that is too short to list them
+	next = []byte{0x01, 0x04,
+		0x06, 0x00, 0x01, 0x09, 0x05, 0x00,
+		0x07, 0x0b, 0x01, 0x00, 0x01, 0x01, 0x00, 0x0c, 0x05, 0x00,
+		0x08, 0x0d, 0xe0, 0xff, 0xff, 0xff, 0x09,
+	}
+	_, err = magicnumber.X7zHeaders(bytes.NewReader(x7zArchive([]byte("hello"), next)))
+	be.Err(t, err, magicnumber.ErrX7zHeader)
+	// a pack info count that is larger than the header
+	next = []byte{0x01, 0x04, 0x06, 0x00, 0xe0, 0xff, 0xff, 0xff, 0x09}
+	_, err = magicnumber.X7zHeaders(bytes.NewReader(x7zArchive([]byte("hello"), next)))
+	be.Err(t, err, magicnumber.ErrX7zHeader)
+	// a files info count of 16 million files in a header that is too short to name them
+	next = []byte{0x01, 0x05, 0xe0, 0xff, 0xff, 0xff, 0x00, 0x00}
+	x7z := x7zArchive(nil, next)
+	be.Equal(t, magicnumber.X7zCompressArchive, magicnumber.Find(bytes.NewReader(x7z)))
+	_, err = magicnumber.X7zHeaders(bytes.NewReader(x7z))
+	be.Err(t, err, magicnumber.ErrX7zHeader)
+}
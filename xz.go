@@ -0,0 +1,279 @@
+package magicnumber
+
+// Package file xz.go contains the functions that parse the stream headers, the block headers
+// and the index of the XZ compression format.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var ErrXZHeader = errors.New("xz header is invalid")
+
+// XZStream is a stream of a XZ file, most files have a single stream,
+// but concatenated XZ files have one stream for each part.
+type XZStream struct {
+	Offset    int64     // Offset is the position of the stream header
+	Size      int64     // Size is the size of the stream, including the header, the blocks, the index and the footer
+	Check     XZCheck   // Check is the type of the integrity check of the uncompressed data of the blocks
+	Blocks    []XZBlock // Blocks are the compressed blocks, in the stored order
+	IndexSize int64     // IndexSize is the size of the index that lists the blocks
+}
+
+// XZBlock is a compressed block of a XZ stream.
+type XZBlock struct {
+	Offset         int64    // Offset is the position of the block header
+	CompressedSize int64    // CompressedSize is the size of the compressed data, without the block header, padding and check
+	Size           int64    // Size is the size of the uncompressed data
+	Filters        []string // Filters are the names of the filters in the filter chain, such as x86 and LZMA2
+	DictionarySize int64    // DictionarySize is the dictionary size of the LZMA2 filter
+}
+
+// XZCheck is the type of the integrity check of a XZ stream.
+type XZCheck byte
+
+const (
+	XZNone   XZCheck = 0x00 // XZNone has no integrity check
+	XZCRC32  XZCheck = 0x01 // XZCRC32 is the 32-bit cyclic redundancy check
+	XZCRC64  XZCheck = 0x04 // XZCRC64 is the 64-bit cyclic redundancy check
+	XZSHA256 XZCheck = 0x0a // XZSHA256 is the SHA-256 cryptographic hash
+)
+
+// String returns the name of the check type.
+func (c XZCheck) String() string {
+	switch c {
+	case XZNone:
+		return "None"
+	case XZCRC32:
+		return "CRC32"
+	case XZCRC64:
+		return "CRC64"
+	case XZSHA256:
+		return "SHA-256"
+	}
+	return fmt.Sprintf("unknown check %d", byte(c))
+}
+
+// size returns the size in bytes of the check field that follows each block.
+func (c XZCheck) size() int64 {
+	if c > 0x0f {
+		return 0
+	}
+	return [...]int64{0, 4, 4, 4, 8, 8, 8, 16, 16, 16, 32, 32, 32, 64, 64, 64}[c]
+}
+
+const xzHeaderLen = 12 // xzHeaderLen is the size of both the stream header and the stream footer
+
+// XZHeaders reads the streams of a XZ file and returns them in the stored order.
+// The streams are located from the stream footer at the end of the file, which requires
+// the reader to implement [io.Seeker], and the blocks are listed using the index of each stream.
+// The stream padding between and after the streams is skipped.
+func XZHeaders(r io.ReaderAt) ([]XZStream, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	pos := Length(r)
+	if pos < xzHeaderLen*2 {
+		return nil, fmt.Errorf("%w: too small or not a seeker", ErrXZHeader)
+	}
+	streams := []XZStream{}
+	padding := make([]byte, 4)
+	for range maxBlocks {
+		for pos >= int64(len(padding)) {
+			if _, err := r.ReadAt(padding, pos-int64(len(padding))); err != nil {
+				return nil, fmt.Errorf("%w: stream padding at %d", ErrXZHeader, pos)
+			}
+			if !bytes.Equal(padding, []byte{0, 0, 0, 0}) {
+				break
+			}
+			pos -= int64(len(padding))
+		}
+		if pos == 0 {
+			break
+		}
+		stream, err := xzStream(r, pos)
+		if err != nil {
+			return nil, err
+		}
+		streams = append([]XZStream{stream}, streams...)
+		pos = stream.Offset
+	}
+	if len(streams) == 0 {
+		return nil, fmt.Errorf("%w: no streams", ErrXZHeader)
+	}
+	return streams, nil
+}
+
+// xzStream reads the stream that ends at the end offset,
+// using the footer to find the index and the index to find the blocks and the stream header.
+func xzStream(r io.ReaderAt, end int64) (XZStream, error) {
+	le := binary.LittleEndian
+	footer := make([]byte, xzHeaderLen)
+	if _, err := r.ReadAt(footer, end-xzHeaderLen); err != nil || string(footer[10:]) != "YZ" {
+		return XZStream{}, fmt.Errorf("%w: no stream footer at %d", ErrXZHeader, end-xzHeaderLen)
+	}
+	if crc32.ChecksumIEEE(footer[4:10]) != le.Uint32(footer) {
+		return XZStream{}, fmt.Errorf("%w: stream footer checksum at %d", ErrXZHeader, end-xzHeaderLen)
+	}
+	check := XZCheck(footer[9])
+	indexSize := (int64(le.Uint32(footer[4:])) + 1) * 4
+	indexOffset := end - xzHeaderLen - indexSize
+	if indexOffset < xzHeaderLen {
+		return XZStream{}, fmt.Errorf("%w: backward size at %d", ErrXZHeader, end-xzHeaderLen)
+	}
+	index := make([]byte, indexSize)
+	if _, err := r.ReadAt(index, indexOffset); err != nil || index[0] != 0 {
+		return XZStream{}, fmt.Errorf("%w: no index at %d", ErrXZHeader, indexOffset)
+	}
+	if crc32.ChecksumIEEE(index[:indexSize-4]) != le.Uint32(index[indexSize-4:]) {
+		return XZStream{}, fmt.Errorf("%w: index checksum at %d", ErrXZHeader, indexOffset)
+	}
+	records, n := binary.Uvarint(index[1:])
+	if n <= 0 || records > uint64(indexSize) {
+		return XZStream{}, fmt.Errorf("%w: index records at %d", ErrXZHeader, indexOffset)
+	}
+	vals := rar5Vints(index[1+n:], int(records)*2)
+	if len(vals) != int(records)*2 {
+		return XZStream{}, fmt.Errorf("%w: index records at %d", ErrXZHeader, indexOffset)
+	}
+	blocks := make([]XZBlock, records)
+	total := int64(0)
+	for i := range blocks {
+		unpadded := int64(vals[i*2])
+		blocks[i] = XZBlock{CompressedSize: unpadded, Size: int64(vals[i*2+1])}
+		total += xzPadded(unpadded, check)
+	}
+	stream := XZStream{
+		Offset:    indexOffset - total - xzHeaderLen,
+		Size:      end - (indexOffset - total - xzHeaderLen),
+		Check:     check,
+		IndexSize: indexSize,
+		Blocks:    blocks,
+	}
+	if stream.Offset < 0 {
+		return XZStream{}, fmt.Errorf("%w: index sizes at %d", ErrXZHeader, indexOffset)
+	}
+	header := make([]byte, xzHeaderLen)
+	if _, err := r.ReadAt(header, stream.Offset); err != nil ||
+		!bytes.Equal(header[:6], []byte{0xfd, '7', 'z', 'X', 'Z', 0x0}) {
+		return XZStream{}, fmt.Errorf("%w: no stream header at %d", ErrXZHeader, stream.Offset)
+	}
+	if crc32.ChecksumIEEE(header[6:8]) != le.Uint32(header[8:]) || !bytes.Equal(header[6:8], footer[8:10]) {
+		return XZStream{}, fmt.Errorf("%w: stream flags at %d", ErrXZHeader, stream.Offset)
+	}
+	offset := stream.Offset + xzHeaderLen
+	for i := range stream.Blocks {
+		b := &stream.Blocks[i]
+		b.Offset = offset
+		size, err := b.header(r)
+		if err != nil {
+			return XZStream{}, err
+		}
+		offset += xzPadded(b.CompressedSize, check)
+		// the index stores the unpadded size, which includes the block header and the check
+		b.CompressedSize -= size + check.size()
+	}
+	return stream, nil
+}
+
+// xzPadded returns the size of a block in the stream, the block padding is placed
+// between the compressed data and the check, so it is not part of the unpadded size.
+func xzPadded(unpadded int64, check XZCheck) int64 {
+	return (unpadded-check.size()+3)/4*4 + check.size()
+}
+
+// header reads the block header to list the filters and returns the size of the header.
+func (b *XZBlock) header(r io.ReaderAt) (int64, error) {
+	const (
+		filtersMask  = 0x03
+		compressed   = 0x40
+		uncompressed = 0x80
+		lzma2        = 0x21
+	)
+	first := make([]byte, 1)
+	if _, err := r.ReadAt(first, b.Offset); err != nil || first[0] == 0 {
+		return 0, fmt.Errorf("%w: no block header at %d", ErrXZHeader, b.Offset)
+	}
+	size := (int64(first[0]) + 1) * 4
+	p := make([]byte, size)
+	if _, err := r.ReadAt(p, b.Offset); err != nil {
+		return 0, fmt.Errorf("%w: block header at %d", ErrXZHeader, b.Offset)
+	}
+	if crc32.ChecksumIEEE(p[:size-4]) != binary.LittleEndian.Uint32(p[size-4:]) {
+		return 0, fmt.Errorf("%w: block header checksum at %d", ErrXZHeader, b.Offset)
+	}
+	flags := p[1]
+	p = p[2 : size-4]
+	for _, present := range []byte{compressed, uncompressed} {
+		if flags&present == 0 {
+			continue
+		}
+		_, n := binary.Uvarint(p)
+		if n <= 0 {
+			return 0, fmt.Errorf("%w: block sizes at %d", ErrXZHeader, b.Offset)
+		}
+		p = p[n:]
+	}
+	b.Filters = []string{}
+	for range int(flags&filtersMask) + 1 {
+		id, n := binary.Uvarint(p)
+		if n <= 0 {
+			return 0, fmt.Errorf("%w: filter flags at %d", ErrXZHeader, b.Offset)
+		}
+		p = p[n:]
+		propsLen, n := binary.Uvarint(p)
+		if n <= 0 || uint64(len(p)-n) < propsLen {
+			return 0, fmt.Errorf("%w: filter properties at %d", ErrXZHeader, b.Offset)
+		}
+		props := p[n : n+int(propsLen)]
+		p = p[n+int(propsLen):]
+		b.Filters = append(b.Filters, xzFilter(id))
+		if id == lzma2 && len(props) == 1 {
+			b.DictionarySize = xzDictionary(props[0])
+		}
+	}
+	return size, nil
+}
+
+// xzFilter returns the name of the filter identifier.
+func xzFilter(id uint64) string {
+	switch id {
+	case 0x03:
+		return "Delta"
+	case 0x04:
+		return "x86"
+	case 0x05:
+		return "PowerPC"
+	case 0x06:
+		return "IA-64"
+	case 0x07:
+		return "ARM"
+	case 0x08:
+		return "ARM-Thumb"
+	case 0x09:
+		return "SPARC"
+	case 0x0a:
+		return "ARM64"
+	case 0x0b:
+		return "RISC-V"
+	case 0x21:
+		return "LZMA2"
+	}
+	return fmt.Sprintf("unknown filter %#x", id)
+}
+
+// xzDictionary returns the dictionary size of the LZMA2 filter property.
+func xzDictionary(prop byte) int64 {
+	const maxProp = 40
+	switch {
+	case prop == maxProp:
+		return 0xffffffff
+	case prop > maxProp:
+		return 0
+	}
+	return int64(2|prop&1) << (prop/2 + 11)
+}
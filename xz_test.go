@@ -0,0 +1,63 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestXZHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestXZHeaders")
+	_, err := magicnumber.XZHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	b, err := os.ReadFile(tdfile(xzFile))
+	be.Err(t, err, nil)
+	streams, err := magicnumber.XZHeaders(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.Equal(t, 1, len(streams))
+	s := streams[0]
+	be.Equal(t, int64(0), s.Offset)
+	be.Equal(t, int64(len(b)), s.Size)
+	be.Equal(t, magicnumber.XZCRC64, s.Check)
+	be.Equal(t, "CRC64", s.Check.String())
+	be.Equal(t, int64(16), s.IndexSize)
+	be.Equal(t, 1, len(s.Blocks))
+	block := s.Blocks[0]
+	be.Equal(t, int64(12), block.Offset)
+	be.Equal(t, int64(576893), block.CompressedSize)
+	be.Equal(t, int64(3260416), block.Size)
+	be.Equal(t, []string{"LZMA2"}, block.Filters)
+	be.Equal(t, int64(8<<20), block.DictionarySize)
+
+	// concatenated streams can be separated by stream padding
+	two := append(append(append([]byte{}, b...), 0, 0, 0, 0), b...)
+	two = append(two, 0, 0, 0, 0)
+	streams, err = magicnumber.XZHeaders(bytes.NewReader(two))
+	be.Err(t, err, nil)
+	be.Equal(t, 2, len(streams))
+	be.Equal(t, int64(len(b)+4), streams[1].Offset)
+	be.Equal(t, int64(len(b)+4+12), streams[1].Blocks[0].Offset)
+
+	// a corrupt stream footer
+	bad := append([]byte{}, b...)
+	bad[len(bad)-5] ^= 0xff
+	_, err = magicnumber.XZHeaders(bytes.NewReader(bad))
+	be.Err(t, err, magicnumber.ErrXZHeader)
+	_, err = magicnumber.XZHeaders(strings.NewReader(strings.Repeat("not a xz file ", 4)))
+	be.Err(t, err, magicnumber.ErrXZHeader)
+}
+
+func TestXZCheck(t *testing.T) {
+	t.Parallel()
+	t.Log("TestXZCheck")
+	be.Equal(t, "None", magicnumber.XZNone.String())
+	be.Equal(t, "CRC32", magicnumber.XZCRC32.String())
+	be.Equal(t, "SHA-256", magicnumber.XZSHA256.String())
+	be.Equal(t, "unknown check 2", magicnumber.XZCheck(2).String())
+}
@@ -0,0 +1,617 @@
+package magicnumber
+
+// Package file zip.go contains the functions that parse the central directory of the PKWARE Zip archive format.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var (
+	ErrZipEnd     = errors.New("zip end of central directory record not found")
+	ErrZipCentral = errors.New("zip central directory is invalid")
+)
+
+// ZipMethod is the compression method of a zip archive entry.
+type ZipMethod uint16
+
+// String returns the name of the compression method.
+func (m ZipMethod) String() string {
+	names := map[ZipMethod]string{
+		0x0: "stored", 0x1: "shrunk", 0x2: "reduced1", 0x3: "reduced2",
+		0x4: "reduced3", 0x5: "reduced4", 0x6: "imploded", 0x8: "deflated",
+		0x9: "deflate64", 0xa: "ibm terse", 0xc: "bzip2", 0xe: "lzma",
+		0x10: "ibm cmpsc", 0x12: "ibm terse new", 0x13: "ibm lz77 z",
+		0x5d: "zstandard", 0x5e: "mp3", 0x5f: "xz", 0x60: "jpeg",
+		0x61: "wavpack", 0x62: "ppmd", 0x63: "aes encrypted",
+	}
+	if s, ok := names[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown method %d", m)
+}
+
+// ZipEntry is a file entry of the central directory of a zip archive.
+type ZipEntry struct {
+	Name             string    // Name is the unmodified filename bytes of the entry, see DecodedName
+	Comment          string    // Comment is the unmodified file comment bytes of the entry
+	Extra            []byte    // Extra is the extra field of the entry
+	Modified         time.Time // Modified is the MS-DOS date and time of the entry
+	Offset           int64     // Offset is the position of the local file header of the entry
+	CompressedSize   int64     // CompressedSize is the size of the compressed data
+	UncompressedSize int64     // UncompressedSize is the size of the file when uncompressed
+	CRC32            uint32    // CRC32 is the checksum of the uncompressed data
+	ExternalAttrs    uint32    // ExternalAttrs are the host operating system file attributes
+	VersionMadeBy    uint16    // VersionMadeBy is the host system and the zip specification version of the packager
+	VersionNeeded    uint16    // VersionNeeded is the zip specification version required to extract the entry
+	Flags            uint16    // Flags is the general purpose bit flag
+	Method           ZipMethod // Method is the compression method
+}
+
+// IsDir returns true if the entry is a directory.
+func (e ZipEntry) IsDir() bool {
+	return len(e.Name) > 0 && (e.Name[len(e.Name)-1] == '/' || e.Name[len(e.Name)-1] == '\\')
+}
+
+// ZipEncoding is the character encoding of the filename of a zip archive entry.
+type ZipEncoding int
+
+const (
+	ZipASCII       ZipEncoding = iota // ZipASCII is a filename that only uses 7-bit ASCII characters
+	ZipCP437                          // ZipCP437 is the IBM PC code page used by MS-DOS and the original PKZIP
+	ZipCP850                          // ZipCP850 is the Western European MS-DOS code page
+	ZipUTF8                           // ZipUTF8 is a filename flagged as UTF-8 by the general purpose bit 11
+	ZipUnicodePath                    // ZipUnicodePath is a UTF-8 filename in the Info-ZIP Unicode Path extra field
+)
+
+// String returns the name of the encoding.
+func (enc ZipEncoding) String() string {
+	if enc < ZipASCII || enc > ZipUnicodePath {
+		return ""
+	}
+	return [...]string{
+		"ASCII",
+		"CP437",
+		"CP850",
+		"UTF-8",
+		"Info-ZIP Unicode Path",
+	}[enc]
+}
+
+// Decode returns the bytes of the encoding as UTF-8 text.
+func (enc ZipEncoding) Decode(p []byte) string {
+	var cm *charmap.Charmap
+	switch enc {
+	case ZipCP437:
+		cm = charmap.CodePage437
+	case ZipCP850:
+		cm = charmap.CodePage850
+	default:
+		return string(p)
+	}
+	s, err := cm.NewDecoder().Bytes(p)
+	if err != nil {
+		return string(p)
+	}
+	return string(s)
+}
+
+// Encoding returns the character encoding of the filename of the entry.
+//
+// The UTF-8 general purpose flag and a valid Info-ZIP Unicode Path extra field are
+// always used. Otherwise the filename is a legacy MS-DOS code page and is CP850 when it
+// uses any accented letters of CP850 that are box drawing characters in CP437.
+// Filenames of an entry created on a Unix system that are valid UTF-8 are also treated as UTF-8.
+func (e ZipEntry) Encoding() ZipEncoding {
+	const utf8Flag, unixHost = 0x800, 3
+	switch {
+	case e.Flags&utf8Flag != 0:
+		return ZipUTF8
+	case e.unicodePath() != "":
+		return ZipUnicodePath
+	case isASCII(e.Name):
+		return ZipASCII
+	case e.VersionMadeBy>>8 == unixHost && utf8.ValidString(e.Name):
+		return ZipUTF8
+	}
+	for i := range len(e.Name) {
+		switch b := e.Name[i]; {
+		case b >= 0xb5 && b <= 0xb7, b == 0xc6, b == 0xc7, b >= 0xd0 && b <= 0xd8, b == 0xde,
+			b == 0xe0, b >= 0xe2 && b <= 0xe5, b >= 0xe8 && b <= 0xeb, b == 0xed:
+			return ZipCP850
+		}
+	}
+	return ZipCP437
+}
+
+// DecodedName returns the filename of the entry as UTF-8 text, using the detected [ZipEntry.Encoding].
+func (e ZipEntry) DecodedName() string {
+	enc := e.Encoding()
+	if enc == ZipUnicodePath {
+		return e.unicodePath()
+	}
+	return enc.Decode([]byte(e.Name))
+}
+
+// unicodePath returns the UTF-8 filename of the Info-ZIP Unicode Path extra field,
+// or an empty string if the field is missing or the checksum does not match the filename.
+func (e ZipEntry) unicodePath() string {
+	const unicodePathID, version = 0x7075, 1
+	for extra := e.Extra; len(extra) >= 4; {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			return ""
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+		const minLen = 5
+		if id != unicodePathID || len(field) <= minLen || field[0] != version {
+			continue
+		}
+		name := field[minLen:]
+		if binary.LittleEndian.Uint32(field[1:]) != crc32.ChecksumIEEE([]byte(e.Name)) || !utf8.Valid(name) {
+			return ""
+		}
+		return string(name)
+	}
+	return ""
+}
+
+// ZipEnd is the end of central directory record of a zip archive.
+type ZipEnd struct {
+	Comment string // Comment is the unmodified archive comment bytes
+	Offset  int64  // Offset is the position of the end of central directory record
+	Entries int64  // Entries is the total number of entries in the central directory
+	Size    int64  // Size is the length of the central directory in bytes
+	Start   int64  // Start is the position of the first central directory header
+	Prefix  int64  // Prefix is the length of any data prepended to the archive, such as a self-extracting program
+	Disk    uint32 // Disk is the number of this disk of a multi-volume archive
+}
+
+// ZipEntries reads the end of central directory record and the central directory
+// of a zip archive and returns every file entry in the order they are stored.
+// The reader must implement io.Seeker to locate the end of the archive.
+//
+// Zip64 archives are supported and any data prepended to the archive,
+// such as a self-extracting program, is taken into account.
+func ZipEntries(r io.ReaderAt) ([]ZipEntry, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	end, err := ZipEndRecord(r)
+	if err != nil {
+		return nil, err
+	}
+	if end.Entries == 0 {
+		return []ZipEntry{}, nil
+	}
+	const headerLen = 46
+	if end.Size < headerLen || end.Start < 0 || end.Size > end.Offset-end.Start {
+		return nil, fmt.Errorf("%w: directory at %d of %d bytes", ErrZipCentral, end.Start, end.Size)
+	}
+	p := make([]byte, end.Size)
+	if _, err := r.ReadAt(p, end.Start); err != nil {
+		return nil, fmt.Errorf("zip central directory: %w", err)
+	}
+	entries := make([]ZipEntry, 0, min(end.Entries, end.Size/headerLen))
+	for i := int64(0); i < end.Entries; i++ {
+		entry, n, err := zipCentral(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d", err, i)
+		}
+		entry.Offset += end.Prefix
+		entries = append(entries, entry)
+		p = p[n:]
+	}
+	return entries, nil
+}
+
+// ZipEndRecord locates and returns the end of central directory record of a zip archive.
+// The reader must implement io.Seeker to locate the end of the archive.
+func ZipEndRecord(r io.ReaderAt) (ZipEnd, error) {
+	if r == nil {
+		return ZipEnd{}, ErrNilReader
+	}
+	length := Length(r)
+	const eocdLen, maxComment = 22, 0xffff
+	if length < eocdLen {
+		return ZipEnd{}, ErrZipEnd
+	}
+	size := min(length, eocdLen+maxComment)
+	p := make([]byte, size)
+	if _, err := r.ReadAt(p, length-size); err != nil && !errors.Is(err, io.EOF) {
+		return ZipEnd{}, fmt.Errorf("zip end record: %w", err)
+	}
+	sig := []byte{'P', 'K', 0x5, 0x6}
+	i := len(p) - eocdLen
+	for ; i >= 0; i-- {
+		if !bytes.Equal(p[i:i+4], sig) {
+			continue
+		}
+		commentLen := int(binary.LittleEndian.Uint16(p[i+20:]))
+		if i+eocdLen+commentLen <= len(p) {
+			break
+		}
+	}
+	if i < 0 {
+		return ZipEnd{}, ErrZipEnd
+	}
+	rec := p[i:]
+	commentLen := int(binary.LittleEndian.Uint16(rec[20:]))
+	end := ZipEnd{
+		Comment: string(rec[eocdLen : eocdLen+commentLen]),
+		Offset:  length - size + int64(i),
+		Entries: int64(binary.LittleEndian.Uint16(rec[10:])),
+		Size:    int64(binary.LittleEndian.Uint32(rec[12:])),
+		Start:   int64(binary.LittleEndian.Uint32(rec[16:])),
+		Disk:    uint32(binary.LittleEndian.Uint16(rec[4:])),
+	}
+	directoryEnd := end.Offset
+	if end.Entries == 0xffff || end.Size == 0xffffffff || end.Start == 0xffffffff {
+		// a missing zip64 record keeps the 32-bit values, which are validated by the central directory
+		if err := zip64End(r, &end); err == nil {
+			const locatorLen, zip64Len = 20, 56
+			directoryEnd = end.Offset - locatorLen - zip64Len
+		}
+	}
+	// correct the offsets of archives with prepended data, such as self-extracting programs
+	if prefix := directoryEnd - (end.Start + end.Size); prefix > 0 {
+		end.Prefix = prefix
+		end.Start += prefix
+	}
+	return end, nil
+}
+
+// zip64End reads the Zip64 end of central directory locator and record that precede
+// the end of central directory record and updates the end with the 64-bit values.
+func zip64End(r io.ReaderAt, end *ZipEnd) error {
+	const locatorLen = 20
+	loc := make([]byte, locatorLen)
+	if _, err := r.ReadAt(loc, end.Offset-locatorLen); err != nil {
+		return fmt.Errorf("%w: zip64 locator: %w", ErrZipEnd, err)
+	}
+	if !bytes.Equal(loc[:4], []byte{'P', 'K', 0x6, 0x7}) {
+		return fmt.Errorf("%w: zip64 locator signature", ErrZipEnd)
+	}
+	const recordLen = 56
+	rec := make([]byte, recordLen)
+	sig := []byte{'P', 'K', 0x6, 0x6}
+	// the stored offset is wrong for archives with prepended data, so also try the expected position
+	for _, offset := range []int64{int64(binary.LittleEndian.Uint64(loc[8:])), end.Offset - locatorLen - recordLen} {
+		if offset < 0 {
+			continue
+		}
+		if _, err := r.ReadAt(rec, offset); err == nil && bytes.Equal(rec[:4], sig) {
+			break
+		}
+		clear(rec)
+	}
+	if !bytes.Equal(rec[:4], sig) {
+		return fmt.Errorf("%w: zip64 record signature", ErrZipEnd)
+	}
+	end.Disk = binary.LittleEndian.Uint32(rec[16:])
+	end.Entries = int64(binary.LittleEndian.Uint64(rec[32:]))
+	end.Size = int64(binary.LittleEndian.Uint64(rec[40:]))
+	end.Start = int64(binary.LittleEndian.Uint64(rec[48:]))
+	if end.Entries < 0 || end.Size < 0 || end.Start < 0 {
+		return fmt.Errorf("%w: zip64 record values", ErrZipEnd)
+	}
+	return nil
+}
+
+// zipCentral parses the central directory file header at the start of p
+// and returns the entry and the length of the header.
+func zipCentral(p []byte) (ZipEntry, int, error) {
+	const headerLen = 46
+	if len(p) < headerLen || !bytes.Equal(p[:4], []byte{'P', 'K', 0x1, 0x2}) {
+		return ZipEntry{}, 0, ErrZipCentral
+	}
+	le := binary.LittleEndian
+	nameLen := int(le.Uint16(p[28:]))
+	extraLen := int(le.Uint16(p[30:]))
+	commentLen := int(le.Uint16(p[32:]))
+	n := headerLen + nameLen + extraLen + commentLen
+	if len(p) < n {
+		return ZipEntry{}, 0, ErrZipCentral
+	}
+	entry := ZipEntry{
+		VersionMadeBy:    le.Uint16(p[4:]),
+		VersionNeeded:    le.Uint16(p[6:]),
+		Flags:            le.Uint16(p[8:]),
+		Method:           ZipMethod(le.Uint16(p[10:])),
+		Modified:         DosTime(le.Uint16(p[14:]), le.Uint16(p[12:])),
+		CRC32:            le.Uint32(p[16:]),
+		CompressedSize:   int64(le.Uint32(p[20:])),
+		UncompressedSize: int64(le.Uint32(p[24:])),
+		ExternalAttrs:    le.Uint32(p[38:]),
+		Offset:           int64(le.Uint32(p[42:])),
+		Name:             string(p[headerLen : headerLen+nameLen]),
+		Extra:            p[headerLen+nameLen : headerLen+nameLen+extraLen],
+		Comment:          string(p[headerLen+nameLen+extraLen : n]),
+	}
+	zip64Extra(&entry)
+	return entry, n, nil
+}
+
+// zip64Extra replaces the 32-bit sizes and offset of the entry with the values
+// of the Zip64 extended information extra field, when they are present.
+func zip64Extra(entry *ZipEntry) {
+	const zip64ID, max32 = 0x0001, 0xffffffff
+	for extra := entry.Extra; len(extra) >= 4; {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			return
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+		if id != zip64ID {
+			continue
+		}
+		next := func(v *int64) {
+			if *v != max32 || len(field) < 8 {
+				return
+			}
+			*v = int64(binary.LittleEndian.Uint64(field))
+			field = field[8:]
+		}
+		next(&entry.UncompressedSize)
+		next(&entry.CompressedSize)
+		next(&entry.Offset)
+		return
+	}
+}
+
+// DosTime returns the time of the MS-DOS date and time values, that are used by
+// many DOS era archivers. The time has a two second resolution and no time zone.
+// A zero time is returned for invalid values.
+func DosTime(date, clock uint16) time.Time {
+	if date == 0 {
+		return time.Time{}
+	}
+	const epoch = 1980
+	year := int(date>>9) + epoch
+	month := time.Month(date >> 5 & 0xf)
+	day := int(date & 0x1f)
+	if month < time.January || month > time.December || day < 1 {
+		return time.Time{}
+	}
+	return time.Date(year, month, day,
+		int(clock>>11), int(clock>>5&0x3f), int(clock&0x1f)*2, 0, time.UTC)
+}
+
+// zipMethods returns the pkComp classification of the compression methods used in the central directory.
+// Any legacy method used by an entry takes precedence over the modern methods,
+// with Reduce, the least supported method, first followed by Implode and then Shrink.
+func zipMethods(entries []ZipEntry) pkComp {
+	found := map[pkComp]bool{}
+	for _, e := range entries {
+		found[pkMethod(uint16(e.Method))] = true
+	}
+	for _, comp := range []pkComp{pkReduce, pkImplode, pkShrink} {
+		if found[comp] {
+			return comp
+		}
+	}
+	return pkZip
+}
+
+// ZipProgram is the likely program that created a zip archive.
+type ZipProgram int
+
+const (
+	ZipUnknown ZipProgram = iota // ZipUnknown is an unidentified zip packager
+	PKZIP09                      // PKZIP09 is PKZIP 0.80 to 0.92 for MS-DOS, which created identical archives
+	PKZIP10                      // PKZIP10 is PKZIP 1.0x for MS-DOS
+	PKZIP11                      // PKZIP11 is PKZIP 1.1x for MS-DOS
+	PKZIP20                      // PKZIP20 is PKZIP 2.0x for MS-DOS
+	PKZIP25                      // PKZIP25 is PKZIP 2.5 or later, or another program using a newer specification
+	InfoZIP                      // InfoZIP is the Info-ZIP Zip for Unix and other platforms
+	WinZip                       // WinZip is WinZip for Windows
+	SevenZip                     // SevenZip is 7-Zip or p7zip
+)
+
+// String returns the name of the program.
+func (prog ZipProgram) String() string {
+	if prog < ZipUnknown || prog > SevenZip {
+		return ""
+	}
+	return [...]string{
+		"unknown",
+		"PKZIP 0.8x or 0.9x",
+		"PKZIP 1.0x",
+		"PKZIP 1.1x",
+		"PKZIP 2.0x",
+		"PKZIP 2.5 or later",
+		"Info-ZIP",
+		"WinZip",
+		"7-Zip",
+	}[prog]
+}
+
+// ZipCreator is the fingerprint of the program that created a zip archive.
+type ZipCreator struct {
+	Program ZipProgram // Program is the likely program that created the archive
+	Host    string     // Host is the operating system of the program, from the version made by
+	Version string     // Version is the zip specification version of the program, from the version made by
+	Options []string   // Options are the compression options found in the general purpose flags of the entries
+}
+
+// ZipFingerprint returns the likely program and version that created the zip archive.
+// It uses the version made by and version needed to extract values, the host operating system,
+// the extra fields, the flags and the compression method options of the central directory entries.
+//
+// PKZIP 0.8x and 0.9x created identical archives and cannot be told apart.
+// WinZip and PKZIP 2.0x both use version 2.0 on MS-DOS, but PKZIP 2.0x for DOS
+// is limited to uppercase 8.3 filenames. The NTFS timestamps of 7-Zip for Windows cannot be
+// told apart from those of WinZip and PKZIP for Windows, so those archives are unknown.
+func ZipFingerprint(r io.ReaderAt) (ZipCreator, error) {
+	entries, err := ZipEntries(r)
+	if err != nil {
+		return ZipCreator{}, err
+	}
+	if len(entries) == 0 {
+		return ZipCreator{Program: ZipUnknown, Options: []string{}}, nil
+	}
+	made := entries[0].VersionMadeBy
+	const ver = 10
+	c := ZipCreator{
+		Program: zipProgram(entries),
+		Host:    zipHost(byte(made >> 8)),
+		Version: fmt.Sprintf("%d.%d", (made&0xff)/ver, (made&0xff)%ver),
+		Options: zipOptions(entries),
+	}
+	return c, nil
+}
+
+// zipProgram returns the likely program that created the archive entries.
+func zipProgram(entries []ZipEntry) ZipProgram {
+	const (
+		msdos   = 0
+		unix    = 3
+		ntfs    = 10
+		v10     = 10
+		v11     = 11
+		v20     = 20
+		v25     = 25
+		winzip  = 63
+		implode = 6
+	)
+	first := entries[0]
+	host, ver := first.VersionMadeBy>>8, first.VersionMadeBy&0xff
+	imploded, shortNames := false, true
+	extras := map[uint16]bool{}
+	for _, e := range entries {
+		for _, id := range zipExtraIDs(e.Extra) {
+			extras[id] = true
+		}
+		imploded = imploded || e.Method == implode
+		shortNames = shortNames && dosName(e.Name)
+	}
+	const (
+		ntfsExtra   = 0x000a
+		timeExtra   = 0x5455 // Info-ZIP extended timestamp
+		unixExtra   = 0x7875 // Info-ZIP new Unix
+		unixExtraV1 = 0x5855 // Info-ZIP old Unix
+		aesExtra    = 0x9901
+	)
+	switch {
+	case extras[timeExtra], extras[unixExtra], extras[unixExtraV1]:
+		return InfoZIP
+	// the NTFS timestamps are written by 7-Zip, WinZip and PKZIP 2.5 or later for Windows,
+	// but only p7zip also uses the Unix host
+	case extras[ntfsExtra] && host == unix:
+		return SevenZip
+	case extras[ntfsExtra]:
+		return ZipUnknown
+	case extras[aesExtra], ver == winzip:
+		return WinZip
+	case host == unix:
+		return InfoZIP
+	case host != msdos && host != ntfs:
+		return ZipUnknown
+	case ver == v10 && imploded:
+		return PKZIP10
+	case ver == v10:
+		return PKZIP09
+	case ver == v11:
+		return PKZIP11
+	case ver == v20 && shortNames:
+		return PKZIP20
+	case ver == v20:
+		return WinZip
+	case ver >= v25:
+		return PKZIP25
+	}
+	return ZipUnknown
+}
+
+// zipExtraIDs returns the header ids of the extra field.
+func zipExtraIDs(extra []byte) []uint16 {
+	ids := []uint16{}
+	for len(extra) >= 4 {
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		ids = append(ids, binary.LittleEndian.Uint16(extra))
+		extra = extra[4+size:]
+	}
+	return ids
+}
+
+// dosName returns true if every element of the path is an uppercase MS-DOS 8.3 filename.
+func dosName(name string) bool {
+	for _, elem := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		base, ext, _ := strings.Cut(elem, ".")
+		const maxBase, maxExt = 8, 3
+		if base == "" || len(base) > maxBase || len(ext) > maxExt || strings.ContainsAny(ext, ". ") ||
+			strings.Contains(base, " ") || strings.ToUpper(elem) != elem {
+			return false
+		}
+	}
+	return true
+}
+
+// zipHost returns the name of the host operating system of the version made by value.
+func zipHost(host byte) string {
+	hosts := [...]string{
+		"MS-DOS", "Amiga", "OpenVMS", "Unix", "VM/CMS", "Atari ST", "OS/2 HPFS",
+		"Macintosh", "Z-System", "CP/M", "Windows NTFS", "MVS", "VSE", "Acorn RISC",
+		"VFAT", "alternate MVS", "BeOS", "Tandem", "OS/400", "OS X",
+	}
+	if int(host) < len(hosts) {
+		return hosts[host]
+	}
+	return fmt.Sprintf("unknown host %d", host)
+}
+
+// zipOptions returns the unique compression method options of the entries, in the order they are found.
+func zipOptions(entries []ZipEntry) []string {
+	const (
+		reduce1   = 2
+		reduce4   = 5
+		implode   = 6
+		deflate   = 8
+		deflate64 = 9
+		option1   = 0x2
+		option2   = 0x4
+	)
+	opts := []string{}
+	for _, e := range entries {
+		var opt string
+		switch m := e.Method; {
+		case m >= reduce1 && m <= reduce4:
+			opt = fmt.Sprintf("reduce compression factor %d", m-1)
+		case m == implode:
+			dict, trees := "4K", 2
+			if e.Flags&option1 != 0 {
+				dict = "8K"
+			}
+			if e.Flags&option2 != 0 {
+				trees = 3
+			}
+			opt = fmt.Sprintf("implode %s sliding dictionary, %d Shannon-Fano trees", dict, trees)
+		case m == deflate, m == deflate64:
+			levels := [...]string{"normal", "maximum", "fast", "super fast"}
+			opt = "deflate " + levels[e.Flags>>1&0x3] + " compression"
+		default:
+			continue
+		}
+		if !slices.Contains(opts, opt) {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
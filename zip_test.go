@@ -0,0 +1,232 @@
+package magicnumber_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestZipEntries(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZipEntries")
+	entries, err := magicnumber.ZipEntries(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+	be.Equal(t, 0, len(entries))
+
+	r, err := os.Open(tdfile("PKZ110.ZIP"))
+	be.Err(t, err, nil)
+	defer r.Close()
+	entries, err = magicnumber.ZipEntries(r)
+	be.Err(t, err, nil)
+	be.Equal(t, 15, len(entries))
+	first := entries[0]
+	be.Equal(t, "TEST.ANS", first.Name)
+	be.Equal(t, magicnumber.ZipMethod(1), first.Method)
+	be.Equal(t, "shrunk", first.Method.String())
+	be.Equal(t, int64(63), first.CompressedSize)
+	be.Equal(t, int64(68), first.UncompressedSize)
+	be.Equal(t, uint32(0x5ce2f707), first.CRC32)
+	be.Equal(t, int64(0), first.Offset)
+	be.Equal(t, time.Date(2012, time.September, 19, 14, 21, 52, 0, time.UTC), first.Modified)
+	be.Equal(t, "imploded", entries[2].Method.String())
+	// PKZ110.ZIP starts with a shrunk entry but also contains imploded entries
+	be.True(t, magicnumber.PkImplode(r))
+	be.True(t, !magicnumber.PkShrink(r))
+	be.Equal(t, magicnumber.PKWAREZipImplode, magicnumber.Find(r))
+
+	// prepended data, such as a self-extractor, moves the offsets
+	p, err := os.ReadFile(tdfile(zipStoreFile))
+	be.Err(t, err, nil)
+	prefix := append(bytes.Repeat([]byte{0}, 100), p...)
+	end, err := magicnumber.ZipEndRecord(bytes.NewReader(prefix))
+	be.Err(t, err, nil)
+	be.Equal(t, int64(100), end.Prefix)
+	entries, err = magicnumber.ZipEntries(bytes.NewReader(prefix))
+	be.Err(t, err, nil)
+	be.Equal(t, 15, len(entries))
+	be.Equal(t, int64(100), entries[0].Offset)
+	for _, e := range entries {
+		be.Equal(t, "stored", e.Method.String())
+	}
+
+	_, err = magicnumber.ZipEntries(bytes.NewReader(p[:len(p)-30]))
+	be.Err(t, err, magicnumber.ErrZipEnd)
+	_, err = magicnumber.ZipEntries(bytes.NewReader(p[:30]))
+	be.Err(t, err, magicnumber.ErrZipEnd)
+}
+
+func TestZipEntriesMethods(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZipEntriesMethods")
+	tests := []struct {
+		name string
+		want magicnumber.Signature
+	}{
+		{"PKZ80A1.ZIP", magicnumber.PKWAREZipShrink},
+		{"PKZ80B1.ZIP", magicnumber.PKWAREZipReduce},
+		{"PKZ90B4.ZIP", magicnumber.PKWAREZipReduce},
+		{"PKZ110ES.ZIP", magicnumber.PKWAREZipShrink},
+		{"PKZ110EX.ZIP", magicnumber.PKWAREZipImplode},
+		{"PKZ204EX.ZIP", magicnumber.PKWAREZip},
+		{"PKZ204E0.ZIP", magicnumber.PKWAREZip},
+	}
+	// a finder that is reused for each archive classifies every reader
+	find := *magicnumber.New()
+	for _, tt := range tests {
+		r, err := os.Open(tdfile(tt.name))
+		be.Err(t, err, nil)
+		be.Equal(t, tt.want, magicnumber.Find(r))
+		be.True(t, find[tt.want](r))
+		r.Close()
+	}
+	// a reader that is reset with other content is classified again
+	p, err := os.ReadFile(tdfile(zipStoreFile))
+	be.Err(t, err, nil)
+	r := bytes.NewReader(p)
+	be.True(t, find[magicnumber.PKWAREZip](r))
+	r.Reset([]byte("not a zip archive, just some plain text"))
+	be.True(t, !find[magicnumber.PKWAREZip](r))
+	// a central directory that starts after the end record
+	const start = 16
+	binary.LittleEndian.PutUint32(p[len(p)-22+start:], 0xffffff00)
+	_, err = magicnumber.ZipEntries(bytes.NewReader(p))
+	be.Err(t, err, magicnumber.ErrZipCentral)
+}
+
+func TestDosTime(t *testing.T) {
+	t.Parallel()
+	t.Log("TestDosTime")
+	be.Equal(t, time.Time{}, magicnumber.DosTime(0, 0))
+	be.Equal(t, time.Time{}, magicnumber.DosTime(0x0000|13<<5|1, 0))
+	be.Equal(t, time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC), magicnumber.DosTime(1<<5|1, 0))
+}
+
+func TestZipEncoding(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZipEncoding")
+	r, err := os.Open(tdfile("τεχτƒιℓε.zip"))
+	be.Err(t, err, nil)
+	defer r.Close()
+	entries, err := magicnumber.ZipEntries(r)
+	be.Err(t, err, nil)
+	be.Equal(t, 1, len(entries))
+	be.Equal(t, magicnumber.ZipUTF8, entries[0].Encoding())
+	be.Equal(t, "τεχτƒιℓε.τχτ", entries[0].DecodedName())
+
+	unicodePath := func(raw, name string) []byte {
+		field := []byte{0x75, 0x70, 0, 0, 1, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint16(field[2:], uint16(5+len(name)))
+		binary.LittleEndian.PutUint32(field[5:], crc32.ChecksumIEEE([]byte(raw)))
+		return append(field, name...)
+	}
+	const fat, unix = 0 << 8, 3 << 8
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, fh := range []*zip.FileHeader{
+		{Name: "README.TXT", CreatorVersion: fat},
+		{Name: "\x8eRGER.TXT", NonUTF8: true, CreatorVersion: fat},
+		{Name: "\xb5RVORE.TXT", NonUTF8: true, CreatorVersion: fat},
+		{Name: "ÁRVORE.TXT", CreatorVersion: fat},
+		{Name: "\x8eRGER.TXT", NonUTF8: true, CreatorVersion: fat, Extra: unicodePath("\x8eRGER.TXT", "Ärger.txt")},
+		{Name: "\x8eRGER.TXT", NonUTF8: true, CreatorVersion: fat, Extra: unicodePath("OTHER.TXT", "Ärger.txt")},
+		{Name: "ärger.txt", NonUTF8: true, CreatorVersion: unix},
+	} {
+		_, err := zw.CreateHeader(fh)
+		be.Err(t, err, nil)
+	}
+	be.Err(t, zw.Close(), nil)
+	entries, err = magicnumber.ZipEntries(bytes.NewReader(buf.Bytes()))
+	be.Err(t, err, nil)
+	tests := []struct {
+		enc  magicnumber.ZipEncoding
+		name string
+	}{
+		{magicnumber.ZipASCII, "README.TXT"},
+		{magicnumber.ZipCP437, "ÄRGER.TXT"},
+		{magicnumber.ZipCP850, "ÁRVORE.TXT"},
+		{magicnumber.ZipUTF8, "ÁRVORE.TXT"},
+		{magicnumber.ZipUnicodePath, "Ärger.txt"},
+		{magicnumber.ZipCP437, "ÄRGER.TXT"},
+		{magicnumber.ZipUTF8, "ärger.txt"},
+	}
+	be.Equal(t, len(tests), len(entries))
+	for i, tt := range tests {
+		be.Equal(t, tt.enc, entries[i].Encoding())
+		be.Equal(t, tt.name, entries[i].DecodedName())
+	}
+	be.Equal(t, "CP850", magicnumber.ZipCP850.String())
+}
+
+func TestZipFingerprint(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZipFingerprint")
+	tests := []struct {
+		name    string
+		program magicnumber.ZipProgram
+		version string
+		options []string
+	}{
+		{"PKZ80A1.ZIP", magicnumber.PKZIP09, "1.0", []string{}},
+		{"PKZ90B2.ZIP", magicnumber.PKZIP09, "1.0", []string{"reduce compression factor 2"}},
+		{"PKZ90B4.ZIP", magicnumber.PKZIP09, "1.0", []string{"reduce compression factor 4"}},
+		{"PKZ110.ZIP", magicnumber.PKZIP11, "1.1", []string{"implode 4K sliding dictionary, 2 Shannon-Fano trees"}},
+		{"PKZ110ES.ZIP", magicnumber.PKZIP11, "1.1", []string{}},
+		{"PKZ204E0.ZIP", magicnumber.PKZIP20, "2.0", []string{}},
+		{"PKZ204EN.ZIP", magicnumber.PKZIP20, "2.0", []string{"deflate normal compression"}},
+		{"PKZ204EX.ZIP", magicnumber.PKZIP20, "2.0", []string{"deflate maximum compression"}},
+		{"PKZ204EF.ZIP", magicnumber.PKZIP20, "2.0", []string{"deflate fast compression"}},
+		{"PKZ204ES.ZIP", magicnumber.PKZIP20, "2.0", []string{"deflate super fast compression"}},
+		{"τεχτƒιℓε.zip", magicnumber.InfoZIP, "2.0", []string{"deflate normal compression"}},
+		{"τεχτƒιℓε.encrypted.zip", magicnumber.SevenZip, "6.3", []string{}},
+	}
+	for _, tt := range tests {
+		r, err := os.Open(tdfile(tt.name))
+		be.Err(t, err, nil)
+		c, err := magicnumber.ZipFingerprint(r)
+		be.Err(t, err, nil)
+		be.Equal(t, tt.program, c.Program)
+		be.Equal(t, tt.version, c.Version)
+		be.Equal(t, tt.options, c.Options)
+		r.Close()
+	}
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	_, err := zw.CreateHeader(&zip.FileHeader{Name: "Long File Name.txt", Method: zip.Deflate})
+	be.Err(t, err, nil)
+	be.Err(t, zw.Close(), nil)
+	c, err := magicnumber.ZipFingerprint(bytes.NewReader(buf.Bytes()))
+	be.Err(t, err, nil)
+	be.Equal(t, magicnumber.WinZip, c.Program)
+	be.Equal(t, "MS-DOS", c.Host)
+	be.Equal(t, "PKZIP 0.8x or 0.9x", magicnumber.PKZIP09.String())
+
+	// the NTFS timestamps extra field of a Windows archiver, such as WinZip or PKZIP 2.5
+	ntfs := []byte{0x0a, 0x00, 0x20, 0x00, 0, 0, 0, 0, 0x01, 0x00, 0x18, 0x00}
+	ntfs = append(ntfs, make([]byte, 24)...)
+	for _, tt := range []struct {
+		host    uint16
+		program magicnumber.ZipProgram
+	}{
+		{0, magicnumber.ZipUnknown},
+		{3, magicnumber.SevenZip},
+	} {
+		buf.Reset()
+		zw = zip.NewWriter(buf)
+		_, err = zw.CreateHeader(&zip.FileHeader{
+			Name: "Long File Name.txt", Method: zip.Deflate, CreatorVersion: tt.host << 8, Extra: ntfs,
+		})
+		be.Err(t, err, nil)
+		be.Err(t, zw.Close(), nil)
+		c, err = magicnumber.ZipFingerprint(bytes.NewReader(buf.Bytes()))
+		be.Err(t, err, nil)
+		be.Equal(t, tt.program, c.Program)
+	}
+}
@@ -0,0 +1,182 @@
+package magicnumber
+
+// Package file zoo.go contains the functions that parse the archive header and
+// the directory entries of the Zoo archive format by Rahul Dhesi.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrZooHeader = errors.New("zoo header is invalid")
+
+// zooTag is the tag of the Zoo archive header and directory entries.
+const zooTag = 0xfdc4a7dc
+
+// ZooDirectory is the archive header and the directory entries of a Zoo archive.
+type ZooDirectory struct {
+	Text       string     // Text is the header text, such as "ZOO 2.10 Archive."
+	Version    string     // Version is the version of Zoo that created the archive, taken from the header text
+	MinVersion string     // MinVersion is the minimum version of Zoo needed to extract the archive
+	Comment    string     // Comment is the archive comment, decoded from CP437
+	Entries    []ZooEntry // Entries are the directory entries of the archive, in the stored order
+}
+
+// ZooEntry is a directory entry of a Zoo archive.
+type ZooEntry struct {
+	Name           string    // Name is the MS-DOS 8.3 filename, decoded from CP437
+	LongName       string    // LongName is the long filename, which is empty when it matches the Name
+	Directory      string    // Directory is the directory of the file using slash separators
+	Comment        string    // Comment is the file comment, decoded from CP437
+	Modified       time.Time // Modified is the MS-DOS date and time the file was last modified
+	Offset         int64     // Offset is the position of the compressed data
+	CompressedSize int64     // CompressedSize is the size of the compressed data
+	OriginalSize   int64     // OriginalSize is the size of the file when uncompressed
+	CRC16          uint16    // CRC16 is the checksum of the uncompressed file
+	Method         ZooMethod // Method is the compression method
+	Deleted        bool      // Deleted is true if the entry was deleted and is kept until the archive is packed
+}
+
+// Path returns the directory and the long filename, or the MS-DOS filename, of the entry.
+func (e ZooEntry) Path() string {
+	name := e.Name
+	if e.LongName != "" {
+		name = e.LongName
+	}
+	if e.Directory == "" {
+		return name
+	}
+	return strings.TrimSuffix(e.Directory, "/") + "/" + name
+}
+
+// ZooMethod is the compression method of a Zoo directory entry.
+type ZooMethod int
+
+// String returns the name of the compression method.
+func (m ZooMethod) String() string {
+	switch m {
+	case 0:
+		return "stored"
+	case 1:
+		return "LZW"
+	case 2:
+		return "LZH"
+	}
+	return fmt.Sprintf("unknown method %d", int(m))
+}
+
+// ZooHeaders parses the archive header and walks the chain of directory entries of a Zoo archive.
+// Deleted entries are included and have the Deleted field set.
+func ZooHeaders(r io.ReaderAt) (ZooDirectory, error) {
+	if r == nil {
+		return ZooDirectory{}, ErrNilReader
+	}
+	const (
+		textLen   = 20
+		headerLen = 34
+		typeLen   = 42
+		entryLen  = 51
+	)
+	p := make([]byte, typeLen)
+	n, _ := r.ReadAt(p, 0)
+	le := binary.LittleEndian
+	if n < headerLen || le.Uint32(p[textLen:]) != zooTag {
+		return ZooDirectory{}, fmt.Errorf("%w: no archive tag", ErrZooHeader)
+	}
+	start := le.Uint32(p[24:])
+	if start+le.Uint32(p[28:]) != 0 {
+		return ZooDirectory{}, fmt.Errorf("%w: start offset %d", ErrZooHeader, start)
+	}
+	text, _, _ := bytes.Cut(p[:textLen], []byte{0})
+	dir := ZooDirectory{
+		Text:       strings.TrimRight(string(text), "\x1a"),
+		MinVersion: fmt.Sprintf("%d.%d", p[32], p[33]),
+		Entries:    []ZooEntry{},
+	}
+	if fields := strings.Fields(dir.Text); len(fields) > 1 {
+		dir.Version = fields[1]
+	}
+	// only the type 1 archive header of Zoo 2 has an archive comment
+	if n == typeLen && p[34] == 1 {
+		dir.Comment = DecodeCP437(zooText(r, int64(le.Uint32(p[35:])), int(le.Uint16(p[39:]))))
+	}
+	next := int64(start)
+	for range maxBlocks {
+		entry := make([]byte, entryLen)
+		if n, _ := r.ReadAt(entry, next); n < entryLen || le.Uint32(entry) != zooTag {
+			return dir, fmt.Errorf("%w: no entry tag at %d", ErrZooHeader, next)
+		}
+		following := int64(le.Uint32(entry[6:]))
+		if following == 0 {
+			// the chain ends with an empty entry
+			return dir, nil
+		}
+		if following <= next {
+			return dir, fmt.Errorf("%w: entry at %d links backwards", ErrZooHeader, next)
+		}
+		name, _, _ := bytes.Cut(entry[38:entryLen], []byte{0})
+		e := ZooEntry{
+			Name:           DecodeCP437(name),
+			Comment:        DecodeCP437(zooText(r, int64(le.Uint32(entry[32:])), int(le.Uint16(entry[36:])))),
+			Modified:       DosTime(le.Uint16(entry[14:]), le.Uint16(entry[16:])),
+			Offset:         int64(le.Uint32(entry[10:])),
+			CompressedSize: int64(le.Uint32(entry[24:])),
+			OriginalSize:   int64(le.Uint32(entry[20:])),
+			CRC16:          le.Uint16(entry[18:]),
+			Method:         ZooMethod(entry[5]),
+			Deleted:        entry[30] != 0,
+		}
+		const typeLong = 2
+		if entry[4] == typeLong {
+			zooVariable(r, next+entryLen, &e)
+		}
+		dir.Entries = append(dir.Entries, e)
+		next = following
+	}
+	return dir, fmt.Errorf("%w: too many entries", ErrZooHeader)
+}
+
+// zooVariable reads the variable part of a type 2 directory entry,
+// which holds the long filename and the directory name.
+func zooVariable(r io.ReaderAt, offset int64, e *ZooEntry) {
+	const fixedLen, lengths = 5, 2
+	p := make([]byte, fixedLen+lengths)
+	if n, _ := r.ReadAt(p, offset); n < len(p) {
+		return
+	}
+	size := int(binary.LittleEndian.Uint16(p))
+	if size < lengths {
+		return
+	}
+	vdata := make([]byte, size)
+	if n, _ := r.ReadAt(vdata, offset+fixedLen); n < size {
+		return
+	}
+	nameLen, dirLen := int(vdata[0]), int(vdata[1])
+	if lengths+nameLen+dirLen > size {
+		return
+	}
+	long, _, _ := bytes.Cut(vdata[lengths:lengths+nameLen], []byte{0})
+	dir, _, _ := bytes.Cut(vdata[lengths+nameLen:lengths+nameLen+dirLen], []byte{0})
+	if name := DecodeCP437(long); name != e.Name {
+		e.LongName = name
+	}
+	e.Directory = strings.ReplaceAll(DecodeCP437(dir), "\\", "/")
+}
+
+// zooText returns the text at the offset, such as an archive or file comment.
+func zooText(r io.ReaderAt, offset int64, size int) []byte {
+	if offset <= 0 || size <= 0 {
+		return nil
+	}
+	b := make([]byte, size)
+	if _, err := r.ReadAt(b, offset); err != nil {
+		return nil
+	}
+	return b
+}
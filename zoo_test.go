@@ -0,0 +1,71 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+func TestZooHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZooHeaders")
+	_, err := magicnumber.ZooHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	r, err := os.Open(tdfile(zooFile))
+	be.Err(t, err, nil)
+	defer r.Close()
+	dir, err := magicnumber.ZooHeaders(r)
+	be.Err(t, err, nil)
+	be.Equal(t, "ZOO 2.10 Archive.", dir.Text)
+	be.Equal(t, "2.10", dir.Version)
+	be.Equal(t, "2.0", dir.MinVersion)
+	be.Equal(t, "", dir.Comment)
+	be.Equal(t, 1, len(dir.Entries))
+	e := dir.Entries[0]
+	be.Equal(t, "24mhzhck.txt", e.Path())
+	be.Equal(t, "LZH", e.Method.String())
+	be.Equal(t, int64(113), e.Offset)
+	be.Equal(t, int64(1189), e.CompressedSize)
+	be.Equal(t, int64(2680), e.OriginalSize)
+	be.Equal(t, uint16(7502), e.CRC16)
+	be.Equal(t, time.Date(1992, time.May, 20, 16, 57, 26, 0, time.UTC), e.Modified)
+	be.True(t, !e.Deleted)
+
+	_, err = magicnumber.ZooHeaders(bytes.NewReader([]byte("ZOO 2.10 Archive.\x1a but not really")))
+	be.Err(t, err, magicnumber.ErrZooHeader)
+}
+
+func TestZooLongNames(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZooLongNames")
+	zoo, err := os.ReadFile(tdfile(zooFile))
+	be.Err(t, err, nil)
+	// replace the variable part of the first entry with a long filename and a directory
+	const entry, vdata = 42, 42 + 56
+	long, path := "24MHz hack notes.txt", "docs/hardware"
+	vd := []byte{byte(len(long)), byte(len(path))}
+	vd = append(vd, long...)
+	vd = append(vd, path...)
+	binary.LittleEndian.PutUint16(zoo[entry+51:], uint16(len(vd)))
+	zoo = append(zoo[:vdata:vdata], append(vd, zoo[vdata:]...)...)
+	// fix the offset to the terminating entry and mark the entry as deleted
+	next := binary.LittleEndian.Uint32(zoo[entry+6:])
+	binary.LittleEndian.PutUint32(zoo[entry+6:], next+uint32(len(vd)))
+	zoo[entry+30] = 1
+
+	dir, err := magicnumber.ZooHeaders(bytes.NewReader(zoo))
+	be.Err(t, err, nil)
+	be.Equal(t, 1, len(dir.Entries))
+	e := dir.Entries[0]
+	be.Equal(t, "24mhzhck.txt", e.Name)
+	be.Equal(t, long, e.LongName)
+	be.Equal(t, path, e.Directory)
+	be.Equal(t, "docs/hardware/24MHz hack notes.txt", e.Path())
+	be.True(t, e.Deleted)
+}
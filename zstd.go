@@ -0,0 +1,187 @@
+package magicnumber
+
+// Package file zstd.go contains the functions that parse the frame headers of the Zstandard compression format.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrZStdHeader = errors.New("zstandard header is invalid")
+
+const (
+	zstdFrame      = 0xfd2fb528 // zstdFrame is the magic of a Zstandard frame
+	zstdSkippable  = 0x184d2a50 // zstdSkippable is the magic of a skippable frame, the low 4 bits are user defined
+	zstdDictionary = 0xec30a437 // zstdDictionary is the magic of a Zstandard dictionary
+)
+
+// ZStdStream is the frames of a Zstandard file, or the identifier of a Zstandard dictionary.
+type ZStdStream struct {
+	Frames       []ZStdFrame // Frames are the Zstandard and the skippable frames, in the stored order
+	Dictionary   bool        // Dictionary is true if the file is a dictionary and not compressed data
+	DictionaryID uint32      // DictionaryID is the identifier of the dictionary
+}
+
+// ZStdFrame is a frame of a Zstandard file.
+type ZStdFrame struct {
+	Offset       int64  // Offset is the position of the frame magic
+	Size         int64  // Size is the size of the frame, including the magic and the header
+	Skippable    bool   // Skippable is true for a skippable frame of user data, such as metadata or a seek table
+	UserNibble   int    // UserNibble is the low 4 bits of the magic of a skippable frame
+	WindowSize   int64  // WindowSize is the minimum memory needed to decompress the frame
+	DictionaryID uint32 // DictionaryID is the identifier of the dictionary needed to decompress the frame, or 0 for none
+	ContentSize  int64  // ContentSize is the size of the decompressed data, or -1 when it is not stored
+	Blocks       int    // Blocks is the number of the blocks in the frame
+	Checksum     bool   // Checksum is true if the frame ends with a checksum of the decompressed data
+}
+
+// ZStdHeaders reads the frame headers of a Zstandard file and walks the blocks of each frame.
+// Skippable frames are listed but their content is not read. A Zstandard dictionary returns
+// the dictionary identifier and no frames. Any data after the last frame that is not a frame
+// is ignored.
+func ZStdHeaders(r io.ReaderAt) (ZStdStream, error) {
+	if r == nil {
+		return ZStdStream{}, ErrNilReader
+	}
+	const magicLen = 4
+	le := binary.LittleEndian
+	stream := ZStdStream{Frames: []ZStdFrame{}}
+	p := make([]byte, magicLen*2)
+	if n, _ := r.ReadAt(p, 0); n == len(p) && le.Uint32(p) == zstdDictionary {
+		stream.Dictionary = true
+		stream.DictionaryID = le.Uint32(p[magicLen:])
+		return stream, nil
+	}
+	offset := int64(0)
+	for range maxBlocks {
+		if n, _ := r.ReadAt(p, offset); n < magicLen {
+			break
+		}
+		magic := le.Uint32(p)
+		switch {
+		case magic&^0x0f == zstdSkippable:
+			stream.Frames = append(stream.Frames, ZStdFrame{
+				Offset:      offset,
+				Size:        magicLen*2 + int64(le.Uint32(p[magicLen:])),
+				Skippable:   true,
+				UserNibble:  int(magic & 0x0f),
+				ContentSize: -1,
+			})
+		case magic == zstdFrame:
+			frame, err := zstdHeader(r, offset)
+			if err != nil {
+				return stream, err
+			}
+			stream.Frames = append(stream.Frames, frame)
+		default:
+			if len(stream.Frames) == 0 {
+				return stream, fmt.Errorf("%w: no frame at %d", ErrZStdHeader, offset)
+			}
+			return stream, nil
+		}
+		offset += stream.Frames[len(stream.Frames)-1].Size
+	}
+	if len(stream.Frames) == 0 {
+		return stream, fmt.Errorf("%w: no frames", ErrZStdHeader)
+	}
+	return stream, nil
+}
+
+// zstdHeader reads the frame header and the block headers of the frame at the offset.
+func zstdHeader(r io.ReaderAt, offset int64) (ZStdFrame, error) {
+	const (
+		magicLen    = 4
+		maxHeader   = 14
+		singleSeg   = 0x20
+		reserved    = 0x08
+		checksum    = 0x04
+		blockLen    = 3
+		checksumLen = 4
+		rle         = 1
+		invalid     = 3
+	)
+	p := make([]byte, maxHeader)
+	if n, _ := r.ReadAt(p, offset); n < magicLen+2 {
+		return ZStdFrame{}, fmt.Errorf("%w: frame header at %d", ErrZStdHeader, offset)
+	}
+	desc := p[magicLen]
+	if desc&reserved != 0 {
+		return ZStdFrame{}, fmt.Errorf("%w: frame header descriptor at %d", ErrZStdHeader, offset)
+	}
+	frame := ZStdFrame{
+		Offset:      offset,
+		Checksum:    desc&checksum != 0,
+		ContentSize: -1,
+	}
+	single := desc&singleSeg != 0
+	pos := magicLen + 1
+	if !single {
+		exponent, mantissa := int64(p[pos]>>3), int64(p[pos]&0x07)
+		base := int64(1) << (10 + exponent)
+		frame.WindowSize = base + base/8*mantissa
+		pos++
+	}
+	le := binary.LittleEndian
+	switch desc & 0x03 {
+	case 1:
+		frame.DictionaryID = uint32(p[pos])
+		pos++
+	case 2:
+		frame.DictionaryID = uint32(le.Uint16(p[pos:]))
+		pos += 2
+	case 3:
+		frame.DictionaryID = le.Uint32(p[pos:])
+		pos += 4
+	}
+	switch desc >> 6 {
+	case 0:
+		if single {
+			frame.ContentSize = int64(p[pos])
+			pos++
+		}
+	case 1:
+		// the 2 byte field is offset by 256, as smaller sizes use the 1 byte field
+		const offset2 = 256
+		frame.ContentSize = int64(le.Uint16(p[pos:])) + offset2
+		pos += 2
+	case 2:
+		frame.ContentSize = int64(le.Uint32(p[pos:]))
+		pos += 4
+	case 3:
+		frame.ContentSize = int64(le.Uint64(p[pos:]))
+		pos += 8
+	}
+	if single {
+		frame.WindowSize = frame.ContentSize
+	}
+	block := offset + int64(pos)
+	b := make([]byte, blockLen)
+	for range maxBlocks {
+		if n, _ := r.ReadAt(b, block); n < blockLen {
+			return ZStdFrame{}, fmt.Errorf("%w: block header at %d", ErrZStdHeader, block)
+		}
+		header := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+		last, typ, size := header&1 != 0, header>>1&0x03, int64(header>>3)
+		if typ == invalid {
+			return ZStdFrame{}, fmt.Errorf("%w: reserved block type at %d", ErrZStdHeader, block)
+		}
+		if typ == rle {
+			size = 1
+		}
+		frame.Blocks++
+		block += blockLen + size
+		if last {
+			break
+		}
+	}
+	if frame.Checksum {
+		block += checksumLen
+	}
+	frame.Size = block - offset
+	if n, _ := r.ReadAt(b[:1], block-1); n < 1 {
+		return ZStdFrame{}, fmt.Errorf("%w: truncated frame at %d", ErrZStdHeader, offset)
+	}
+	return frame, nil
+}
@@ -0,0 +1,93 @@
+package magicnumber_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/magicnumber"
+	"github.com/nalgeon/be"
+)
+
+// zstdRaw returns a Zstandard frame of the header that stores the content in raw blocks of the size.
+func zstdRaw(header []byte, content string, size int, checksum bool) []byte {
+	b := append([]byte{0x28, 0xb5, 0x2f, 0xfd}, header...)
+	for {
+		n := min(size, len(content))
+		last := uint32(0)
+		if n == len(content) {
+			last = 1
+		}
+		// the block header is the last block bit, the raw block type 0 and the block size
+		h := last | uint32(n)<<3
+		b = append(b, byte(h), byte(h>>8), byte(h>>16))
+		b = append(b, content[:n]...)
+		content = content[n:]
+		if last == 1 {
+			break
+		}
+	}
+	if checksum {
+		b = append(b, 0xde, 0xad, 0xbe, 0xef)
+	}
+	return b
+}
+
+func TestZStdHeaders(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZStdHeaders")
+	_, err := magicnumber.ZStdHeaders(nil)
+	be.Err(t, err, magicnumber.ErrNilReader)
+
+	// a single segment frame with a 1 byte content size and a checksum
+	single := zstdRaw([]byte{0x24, 5}, "hello", 5, true)
+	// a frame with a window descriptor of 1 MiB, a 2 byte dictionary ID and no content size
+	window := zstdRaw([]byte{0x02, 0x50, 0x39, 0x30}, strings.Repeat("x", 300), 128, false)
+	// a skippable frame with 4 bytes of user data
+	skip := binary.LittleEndian.AppendUint32(nil, 0x184d2a5a)
+	skip = binary.LittleEndian.AppendUint32(skip, 4)
+	skip = append(skip, "meta"...)
+	b := append(append(append([]byte{}, skip...), single...), window...)
+	be.True(t, magicnumber.ZStd(bytes.NewReader(b)))
+	stream, err := magicnumber.ZStdHeaders(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.True(t, !stream.Dictionary)
+	be.Equal(t, 3, len(stream.Frames))
+	f := stream.Frames[0]
+	be.True(t, f.Skippable)
+	be.Equal(t, 10, f.UserNibble)
+	be.Equal(t, int64(12), f.Size)
+	f = stream.Frames[1]
+	be.Equal(t, int64(12), f.Offset)
+	be.Equal(t, int64(len(single)), f.Size)
+	be.Equal(t, int64(5), f.ContentSize)
+	be.Equal(t, int64(5), f.WindowSize)
+	be.Equal(t, 1, f.Blocks)
+	be.True(t, f.Checksum)
+	f = stream.Frames[2]
+	be.Equal(t, int64(len(window)), f.Size)
+	be.Equal(t, int64(-1), f.ContentSize)
+	be.Equal(t, int64(1<<20), f.WindowSize)
+	be.Equal(t, uint32(12345), f.DictionaryID)
+	be.Equal(t, 3, f.Blocks)
+	be.True(t, !f.Checksum)
+
+	// a truncated frame
+	_, err = magicnumber.ZStdHeaders(bytes.NewReader(single[:len(single)-1]))
+	be.Err(t, err, magicnumber.ErrZStdHeader)
+	_, err = magicnumber.ZStdHeaders(strings.NewReader("not a zstd file"))
+	be.Err(t, err, magicnumber.ErrZStdHeader)
+}
+
+func TestZStdDictionary(t *testing.T) {
+	t.Parallel()
+	t.Log("TestZStdDictionary")
+	b := []byte{0x37, 0xa4, 0x30, 0xec, 0x74, 0x81, 0xc8, 0x09, 0, 0, 0, 0}
+	be.True(t, magicnumber.ZStd(bytes.NewReader(b)))
+	stream, err := magicnumber.ZStdHeaders(bytes.NewReader(b))
+	be.Err(t, err, nil)
+	be.True(t, stream.Dictionary)
+	be.Equal(t, uint32(164135284), stream.DictionaryID)
+	be.Equal(t, 0, len(stream.Frames))
+}